@@ -0,0 +1,154 @@
+// Package bkc is a Go client for the BKC PoSA contracts: BKCValidatorSet, StakePool,
+// ValidatorPool and SystemReward. It is what the cli commands are built on and
+// can be imported by other services.
+package bkc
+
+import (
+	"context"
+	"math/big"
+	discover "win/Code/Discover"
+	IValidator "win/Code/IValidator"
+	"win/abi/stakepool"
+	"win/abi/systemreward"
+	"win/abi/validatorset"
+	"win/abi/vldpool"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Backend is what the Client needs from a node. Both *ethclient.Client and the
+// simulated backend of go-ethereum satisfy it.
+type Backend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+	HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error)
+	StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error)
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error)
+}
+
+// Addresses is a full set of the four contracts.
+type Addresses = discover.Addresses
+
+// Validator is an entry of the validator pool or of the active set.
+type Validator = IValidator.Validator
+
+// The values of the BondStatus enum of IBond.sol.
+const (
+	Bonded uint8 = iota
+	Unbonding
+	Unbonded
+)
+
+// Contract names one of the four contracts.
+type Contract int
+
+const (
+	ValidatorSetContract Contract = iota
+	StakePoolContract
+	SystemRewardContract
+	ValidatorPoolContract
+)
+
+func (c Contract) String() string {
+	switch c {
+	case ValidatorSetContract:
+		return "validator set"
+	case StakePoolContract:
+		return "stake pool"
+	case SystemRewardContract:
+		return "system reward"
+	case ValidatorPoolContract:
+		return "validator pool"
+	}
+	return "unknown contract"
+}
+
+// Client wraps the bindings of the four contracts. The bindings are exported for
+// anything the Client doesn't cover yet.
+type Client struct {
+	backend Backend
+	addrs   Addresses
+	block   *big.Int
+
+	ValidatorSet  *validatorset.Validatorset
+	StakePool     *stakepool.Stakepool
+	SystemReward  *systemreward.Systemreward
+	ValidatorPool *vldpool.Vldpool
+}
+
+// NewClient binds the four contracts at addrs.
+func NewClient(backend Backend, addrs Addresses) (*Client, error) {
+	c := &Client{backend: backend, addrs: addrs}
+	var err error
+	if c.ValidatorSet, err = validatorset.NewValidatorset(addrs.ValidatorSet, backend); err != nil {
+		return nil, err
+	}
+	if c.StakePool, err = stakepool.NewStakepool(addrs.StakePool, backend); err != nil {
+		return nil, err
+	}
+	if c.SystemReward, err = systemreward.NewSystemreward(addrs.SystemReward, backend); err != nil {
+		return nil, err
+	}
+	if c.ValidatorPool, err = vldpool.NewVldpool(addrs.ValidatorPool, backend); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Discover finds the other three contracts from the validator set address and binds them.
+func Discover(ctx context.Context, backend Backend, validatorSet common.Address) (*Client, error) {
+	addrs, err := discover.Discover(ctx, backend, validatorSet, nil)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(backend, addrs)
+}
+
+// AtBlock returns a copy of the client whose queries read the state at the given
+// block. A nil number means the latest block.
+func (c *Client) AtBlock(number *big.Int) *Client {
+	copy := *c
+	copy.block = number
+	return &copy
+}
+
+// Block is the block the queries read, nil for the latest one.
+func (c *Client) Block() *big.Int {
+	return c.block
+}
+
+func (c *Client) Backend() Backend {
+	return c.backend
+}
+
+func (c *Client) Addresses() Addresses {
+	return c.addrs
+}
+
+// Verify checks that the four contracts point at each other.
+func (c *Client) Verify(ctx context.Context) error {
+	return discover.Verify(ctx, c.backend, c.addrs, c.block)
+}
+
+// AlreadyInit tells whether init has been called on the contract.
+func (c *Client) AlreadyInit(ctx context.Context, contract Contract) (bool, error) {
+	opts := c.callOpts(ctx)
+	switch contract {
+	case ValidatorSetContract:
+		return c.ValidatorSet.AlreadyInit(opts)
+	case StakePoolContract:
+		return c.StakePool.AlreadyInit(opts)
+	case SystemRewardContract:
+		return c.SystemReward.AlreadyInit(opts)
+	default:
+		return c.ValidatorPool.AlreadyInit(opts)
+	}
+}
+
+func (c *Client) callOpts(ctx context.Context) *bind.CallOpts {
+	return &bind.CallOpts{Context: ctx, BlockNumber: c.block}
+}
@@ -0,0 +1,214 @@
+package bkc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+	"win/abi/stakepool"
+	"win/abi/systemreward"
+	"win/abi/validatorset"
+	"win/abi/vldpool"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// DeployStep is a step of Deploy: the deployment or the init of one contract.
+// Tx is nil when the step was done already.
+type DeployStep struct {
+	Contract  Contract
+	Action    string
+	Tx        *types.Transaction
+	Addresses Addresses
+}
+
+// Deploy brings up the four contracts. It deploys those whose address is zero in
+// addrs, then calls init on ValidatorPool, StakePool, SystemReward and last
+// BKCValidatorSet, whose init updates the validator set and so needs the others
+// ready. Each transaction is waited for before the next one is sent.
+//
+// Steps done already are skipped, so an interrupted deployment is resumed by
+// calling Deploy again with the addresses it reached. An address without code
+// must be a deployment sent by opts.From, which is waited for when it is still
+// pending and sent again when it failed. progress, when not nil, is called with
+// each step once its transaction is sent, before it is mined, or with a nil Tx
+// when it is skipped.
+//
+// With opts.NoSend the missing contracts are only built, with their estimated
+// gas and following nonces, and reported. The inits, which need the contracts,
+// are left out.
+func Deploy(ctx context.Context, backend Backend, opts *bind.TransactOpts, addrs Addresses, progress func(DeployStep)) (Addresses, error) {
+	d := &deployment{ctx: ctx, backend: backend, opts: opts, addrs: addrs, progress: progress}
+	for _, contract := range []struct {
+		contract Contract
+		addr     *common.Address
+		deploy   func() (common.Address, *types.Transaction, error)
+	}{
+		{ValidatorSetContract, &d.addrs.ValidatorSet, func() (common.Address, *types.Transaction, error) {
+			a, tx, _, err := validatorset.DeployValidatorset(opts, backend)
+			return a, tx, err
+		}},
+		{StakePoolContract, &d.addrs.StakePool, func() (common.Address, *types.Transaction, error) {
+			a, tx, _, err := stakepool.DeployStakepool(opts, backend)
+			return a, tx, err
+		}},
+		{SystemRewardContract, &d.addrs.SystemReward, func() (common.Address, *types.Transaction, error) {
+			a, tx, _, err := systemreward.DeploySystemreward(opts, backend)
+			return a, tx, err
+		}},
+		{ValidatorPoolContract, &d.addrs.ValidatorPool, func() (common.Address, *types.Transaction, error) {
+			a, tx, _, err := vldpool.DeployVldpool(opts, backend)
+			return a, tx, err
+		}},
+	} {
+		if err := d.deploy(contract.contract, contract.addr, contract.deploy); err != nil {
+			return d.addrs, err
+		}
+	}
+	if opts.NoSend {
+		return d.addrs, nil
+	}
+
+	c, err := NewClient(backend, d.addrs)
+	if err != nil {
+		return d.addrs, err
+	}
+	a := d.addrs
+	for _, contract := range []struct {
+		contract Contract
+		init     func() (*types.Transaction, error)
+	}{
+		{ValidatorPoolContract, func() (*types.Transaction, error) {
+			return c.ValidatorPool.Init(opts, a.ValidatorSet, a.StakePool)
+		}},
+		{StakePoolContract, func() (*types.Transaction, error) {
+			return c.StakePool.Init(opts, a.ValidatorPool)
+		}},
+		{SystemRewardContract, func() (*types.Transaction, error) {
+			return c.SystemReward.Init(opts, a.ValidatorSet, a.StakePool, a.ValidatorPool)
+		}},
+		{ValidatorSetContract, func() (*types.Transaction, error) {
+			return c.ValidatorSet.Init(opts, a.ValidatorPool, a.SystemReward)
+		}},
+	} {
+		if err := d.init(c, contract.contract, contract.init); err != nil {
+			return d.addrs, err
+		}
+	}
+	return d.addrs, c.Verify(ctx)
+}
+
+type deployment struct {
+	ctx      context.Context
+	backend  Backend
+	opts     *bind.TransactOpts
+	addrs    Addresses
+	progress func(DeployStep)
+}
+
+func (d *deployment) report(contract Contract, action string, tx *types.Transaction) {
+	if d.progress != nil {
+		d.progress(DeployStep{Contract: contract, Action: action, Tx: tx, Addresses: d.addrs})
+	}
+}
+
+// deploy deploys the contract unless *addr already has code.
+func (d *deployment) deploy(contract Contract, addr *common.Address, deploy func() (common.Address, *types.Transaction, error)) error {
+	if *addr != (common.Address{}) {
+		deployed, err := d.resume(contract, *addr)
+		if err != nil || deployed {
+			return err
+		}
+	}
+	a, tx, err := deploy()
+	if err != nil {
+		return fmt.Errorf("can't deploy the %s contract: %w", contract, err)
+	}
+	*addr = a
+	d.report(contract, "deploy", tx)
+	if d.opts.NoSend {
+		// the next deployment is built as if this one was sent
+		d.opts.Nonce = new(big.Int).SetUint64(tx.Nonce() + 1)
+		return nil
+	}
+	if _, err := bind.WaitDeployed(d.ctx, d.backend, tx); err != nil {
+		return fmt.Errorf("can't deploy the %s contract: %w", contract, err)
+	}
+	return nil
+}
+
+// resume tells whether the contract at addr is deployed, waiting for it when its
+// deployment is pending. It is false when the deployment failed.
+func (d *deployment) resume(contract Contract, addr common.Address) (bool, error) {
+	code, err := d.backend.CodeAt(d.ctx, addr, nil)
+	if err != nil {
+		return false, err
+	}
+	if len(code) > 0 {
+		d.report(contract, "deploy", nil)
+		return true, nil
+	}
+
+	// contract addresses come from the deployer and its nonce
+	pending, err := d.backend.PendingNonceAt(d.ctx, d.opts.From)
+	if err != nil {
+		return false, err
+	}
+	nonce := uint64(0)
+	for ; nonce < pending; nonce++ {
+		if crypto.CreateAddress(d.opts.From, nonce) == addr {
+			break
+		}
+	}
+	if nonce == pending {
+		return false, Errorf(KindUsage, "the %s contract %s has no code and wasn't deployed by %s", contract, addr.Hex(), d.opts.From.Hex())
+	}
+	for {
+		mined, err := d.backend.NonceAt(d.ctx, d.opts.From, nil)
+		if err != nil {
+			return false, err
+		}
+		if mined > nonce {
+			break
+		}
+		select {
+		case <-d.ctx.Done():
+			return false, d.ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+	if code, err = d.backend.CodeAt(d.ctx, addr, nil); err != nil || len(code) == 0 {
+		// the deployment was mined but failed, it is sent again
+		return false, err
+	}
+	d.report(contract, "deploy", nil)
+	return true, nil
+}
+
+// init calls init on the contract unless it is initialised already.
+func (d *deployment) init(c *Client, contract Contract, init func() (*types.Transaction, error)) error {
+	done, err := c.AlreadyInit(d.ctx, contract)
+	if err != nil {
+		return err
+	}
+	if done {
+		d.report(contract, "init", nil)
+		return nil
+	}
+	tx, err := init()
+	if err != nil {
+		return fmt.Errorf("can't init the %s contract: %w", contract, err)
+	}
+	d.report(contract, "init", tx)
+	receipt, err := bind.WaitMined(d.ctx, d.backend, tx)
+	if err != nil {
+		return err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return fmt.Errorf("can't init the %s contract: %w", contract, c.FailedError(d.ctx, tx, d.opts.From, receipt))
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package bkc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+	discover "win/Code/Discover"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Kind is the class of an error. The cli turns each kind into its own exit code.
+type Kind int
+
+const (
+	KindUnknown        Kind = iota
+	KindConnection          // the node can't be reached or is not the expected one
+	KindNotInitialised      // init hasn't been called on a contract
+	KindNotFound            // no such validator, delegator, index or contract
+	KindRevert              // the contract reverted the call
+	KindUsage               // bad arguments, flags or configuration
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindConnection:
+		return "connection"
+	case KindNotInitialised:
+		return "not-initialised"
+	case KindNotFound:
+		return "not-found"
+	case KindRevert:
+		return "revert"
+	case KindUsage:
+		return "usage"
+	}
+	return "unknown"
+}
+
+// notInitReason is the require message of the onlyInit modifier of System.sol.
+const notInitReason = "the contract not init yet"
+
+// Error is an error of a known kind. Reason is the revert reason given by the
+// contract, if any, and Revert its entry in the catalogue when it is a known one.
+// Hint tells what to do about the error.
+type Error struct {
+	Kind   Kind
+	Reason string
+	Revert *RevertError
+	Hint   string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches the catalogue entry of the revert reason.
+func (e *Error) Is(target error) bool {
+	return e.Revert != nil && target == e.Revert
+}
+
+// Errorf returns an Error of the given kind.
+func Errorf(kind Kind, format string, a ...interface{}) error {
+	return &Error{Kind: kind, Err: fmt.Errorf(format, a...)}
+}
+
+// KindOf returns the kind of err, classifying it first when it isn't an Error yet.
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(Classify(err), &e) {
+		return e.Kind
+	}
+	return KindUnknown
+}
+
+// Classify wraps err in an Error of the matching kind. Errors that already are
+// an Error, and errors that can't be classified, are returned unchanged.
+func Classify(err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	if reason, ok := revertReason(err); ok {
+		e := &Error{Kind: KindRevert, Reason: reason, Err: err}
+		if r, ok := Reverts[reason]; ok {
+			e.Revert = r
+			e.Hint = r.Hint
+			if r.Kind != KindUnknown {
+				e.Kind = r.Kind
+			}
+		}
+		return e
+	}
+	switch {
+	case errors.Is(err, discover.ErrNotInit):
+		return &Error{Kind: KindNotInitialised, Err: err}
+	case errors.Is(err, bind.ErrNoCode), errors.Is(err, ethereum.NotFound):
+		return &Error{Kind: KindNotFound, Err: err}
+	case isConnection(err):
+		return &Error{Kind: KindConnection, Err: err}
+	}
+	return err
+}
+
+// revertReason tells whether err is a reverted call and returns the reason
+// given by the contract, "" when there is none. The return data is decoded when
+// the node gives it, otherwise the reason is read from the message: geth reports
+// reverts as "execution reverted: reason", Ganache as "VM Exception while
+// processing transaction: revert reason". Gas estimation wraps the former in a
+// plain string.
+func revertReason(err error) (string, bool) {
+	if data, ok := revertData(err); ok {
+		if reason, err := DecodeRevert(data); err == nil {
+			return reason, true
+		}
+	}
+	msg := err.Error()
+	for _, marker := range []string{"execution reverted", "VM Exception while processing transaction: revert"} {
+		i := strings.Index(msg, marker)
+		if i < 0 {
+			continue
+		}
+		reason := strings.TrimPrefix(msg[i+len(marker):], ":")
+		return strings.TrimSpace(reason), true
+	}
+	return "", false
+}
+
+// isConnection tells whether err comes from reaching the node rather than from the node itself.
+func isConnection(err error) bool {
+	var netErr net.Error
+	var httpErr rpc.HTTPError
+	switch {
+	case errors.As(err, &netErr), errors.As(err, &httpErr):
+		return true
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, context.DeadlineExceeded):
+		return true
+	}
+	return false
+}
+
+// indexError wraps the error of reading a public array. Reading past the end
+// reverts without a reason, which is reported as not found.
+func indexError(err error, format string, a ...interface{}) error {
+	msg := fmt.Sprintf(format, a...)
+	err = Classify(err)
+	var e *Error
+	if errors.As(err, &e) && e.Kind == KindRevert && (e.Reason == "" || e.Reason == outOfBounds) {
+		return &Error{Kind: KindNotFound, Err: fmt.Errorf("%s: no such index", msg)}
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// predicted is the error of a check finding that the contract would revert with r.
+func predicted(r *RevertError, format string, a ...interface{}) error {
+	kind := r.Kind
+	if kind == KindUnknown {
+		kind = KindRevert
+	}
+	return &Error{Kind: kind, Reason: r.Reason, Revert: r, Hint: r.Hint, Err: fmt.Errorf(format, a...)}
+}
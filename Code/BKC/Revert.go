@@ -0,0 +1,230 @@
+package bkc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// The selectors of the two revert payloads of Solidity, Error(string) for require
+// and revert, Panic(uint256) for failed asserts, overflows and out of bounds indexes.
+var (
+	errorSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+	panicSelector = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+)
+
+// panicCodes describes the codes of Panic(uint256).
+var panicCodes = map[uint64]string{
+	0x01: "assertion failed",
+	0x11: "arithmetic overflow or underflow",
+	0x12: "division by zero",
+	0x21: "invalid enum value",
+	0x31: "pop on an empty array",
+	0x32: "array index out of bounds",
+	0x41: "out of memory",
+	0x51: "call to an invalid internal function",
+}
+
+// outOfBounds is the reason given to a Panic(0x32) revert.
+var outOfBounds = fmt.Sprintf("panic 0x32: %s", panicCodes[0x32])
+
+// DecodeRevert returns the reason carried by the return data of a reverted call.
+// Error(string) gives its message and Panic(uint256) a description of its code.
+// Empty data, a revert without a reason, gives "".
+func DecodeRevert(data []byte) (string, error) {
+	switch {
+	case len(data) == 0:
+		return "", nil
+	case bytes.HasPrefix(data, errorSelector):
+		return abi.UnpackRevert(data)
+	case bytes.HasPrefix(data, panicSelector) && len(data) == 4+32:
+		code := new(big.Int).SetBytes(data[4:]).Uint64()
+		if desc, ok := panicCodes[code]; ok {
+			return fmt.Sprintf("panic 0x%x: %s", code, desc), nil
+		}
+		return fmt.Sprintf("panic 0x%x", code), nil
+	}
+	return "", fmt.Errorf("unknown revert data %s", hexutil.Encode(data))
+}
+
+// revertData returns the return data attached by geth to the error of a reverted
+// call. Ganache and errors that went through gas estimation don't have it.
+func revertData(err error) ([]byte, bool) {
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return nil, false
+	}
+	s, ok := dataErr.ErrorData().(string)
+	if !ok {
+		return nil, false
+	}
+	data, decodeErr := hexutil.Decode(s)
+	return data, decodeErr == nil
+}
+
+// RevertError is a require reason of the contracts. The catalogue below has one
+// per reason; errors returned by the Client match them with errors.Is, e.g.
+//
+//	if errors.Is(err, bkc.ErrJailInProgress) { ... }
+type RevertError struct {
+	Reason   string
+	Contract string
+	Kind     Kind
+	Hint     string
+
+	// the queue holding the time after which the call can be retried, if any
+	queue *Queue
+	wait  string
+}
+
+func (r *RevertError) Error() string {
+	return r.Reason
+}
+
+func queue(q Queue) *Queue {
+	return &q
+}
+
+// The require reasons of System.sol, inherited by the four contracts.
+var (
+	ErrOnlyBlockProducer = &RevertError{Reason: "the message sender must be the block producer", Contract: "System",
+		Hint: "only the node producing the block calls this function"}
+	ErrAlreadyInit = &RevertError{Reason: "the contract already init", Contract: "System",
+		Hint: "init can only be called once, the contract is ready to use"}
+	ErrNotInit = &RevertError{Reason: notInitReason, Contract: "System", Kind: KindNotInitialised,
+		Hint: "call init on the contract first, see the init-status subcommands"}
+)
+
+// The require reasons of ValidatorPool.sol.
+var (
+	ErrAdminOnly = &RevertError{Reason: "admin only", Contract: "ValidatorPool",
+		Hint: "only the admin account can jail validators, sign with it"}
+	ErrOnlyValidators = &RevertError{Reason: "only validators can call this function", Contract: "ValidatorPool",
+		Hint: "sign with the address of a validator of the pool, see `vldpool validators list`"}
+	ErrNonActiveValidator = &RevertError{Reason: "not allow for non-active validator", Contract: "ValidatorPool",
+		Hint: "the validator must be in the active set, see `validatorset validators list`"}
+	ErrOnlyOtherValidators = &RevertError{Reason: "only other validators can do this operation", Contract: "ValidatorPool",
+		Hint: "sign with another validator of the active set, a validator can't do this on itself"}
+	ErrOnlyValidatorSetContract = &RevertError{Reason: "can only be called from BKCValidatorSet Contract", Contract: "ValidatorPool",
+		Hint: "this function is called by the BKCValidatorSet contract, not by accounts"}
+	ErrNotUnbonded = &RevertError{Reason: "can't do operation when unbonding or bonded", Contract: "ValidatorPool",
+		Hint: "the validator must be unbonded, wait for the end of its unbonding or jail period", queue: queue(UnbondQueue), wait: "validator unbonds"}
+	ErrNotEnoughStake = &RevertError{Reason: "not enough fund to withdraw", Contract: "ValidatorPool",
+		Hint: "the amount must be less than the stake, see `vldpool validator get <address>`"}
+	ErrZeroTopUp = &RevertError{Reason: "can't top up with 0 amount", Contract: "ValidatorPool",
+		Hint: "give an amount greater than 0"}
+	ErrUnbondInProgress = &RevertError{Reason: "unbonding still in progress", Contract: "ValidatorPool",
+		Hint: "the unbonding period isn't over, see `vldpool queue unbond <address>`", queue: queue(UnbondQueue), wait: "validator unbonds"}
+	ErrUnbondJailed = &RevertError{Reason: "can't unbond, validator is jailed, also the validator should not be in the unbonding queue", Contract: "ValidatorPool",
+		Hint: "the validator is jailed, unjail it instead", queue: queue(JailQueue), wait: "validator unjails"}
+	ErrJailInProgress = &RevertError{Reason: "jailing still in progress", Contract: "ValidatorPool",
+		Hint: "the jail period isn't over, see `vldpool queue jail <address>`", queue: queue(JailQueue), wait: "validator unjails"}
+	ErrRemoveInProgress = &RevertError{Reason: "removing still in progress", Contract: "ValidatorPool",
+		Hint: "the remove period isn't over, see `vldpool queue remove <address>`", queue: queue(RemoveQueue), wait: "validator can be removed"}
+	ErrNotInRemoveQueue = &RevertError{Reason: "validator must be in the queue", Contract: "ValidatorPool",
+		Hint: "ask for the removal of the validator first"}
+	ErrJailNonActive = &RevertError{Reason: "can't jail non-active validator", Contract: "ValidatorPool",
+		Hint: "only validators of the active set can be jailed, see `validatorset validators list`"}
+	ErrStakeTooLow = &RevertError{Reason: "can't register to be a validator not enough staking amount sent", Contract: "ValidatorPool",
+		Hint: "send a stake of at least 10 ether"}
+	ErrAlreadyRegistered = &RevertError{Reason: "can't register same validator", Contract: "ValidatorPool",
+		Hint: "the address is already a validator of the pool, top up its stake instead"}
+	ErrRemoveNonValidator = &RevertError{Reason: "can only remove a validator", Contract: "ValidatorPool",
+		Hint: "the signer isn't a validator of the pool, see `vldpool validators list`"}
+)
+
+// The require reasons of StakePool.sol.
+var (
+	ErrStakePoolUnderfunded = &RevertError{Reason: "not enough fund to return to delegator, for some reason...", Contract: "StakePool",
+		Hint: "the balance of the stake pool is lower than the delegations, report it to the operators of the chain"}
+	ErrDelegateNonValidator = &RevertError{Reason: "can't delegate to a non-validator", Contract: "StakePool",
+		Hint: "the address isn't a validator of the pool, see `vldpool validators list`"}
+	ErrDelegateNotUnbonded = &RevertError{Reason: "can't delegate to an bonding delegator or an unbonding delegator", Contract: "StakePool",
+		Hint: "delegations are only accepted while the validator is unbonded, wait for the end of its unbonding", queue: queue(UnbondQueue), wait: "validator unbonds"}
+	ErrUndelegateTooMuch = &RevertError{Reason: "not enough amount to unbond", Contract: "StakePool",
+		Hint: "the amount is more than the delegation, see `stakepool delegation <validator> <delegator>`"}
+)
+
+// The require reasons of SystemReward.sol.
+var (
+	ErrOnlyValidatorSet = &RevertError{Reason: "can only be called from the validator set contract", Contract: "SystemReward",
+		Hint: "this function is called by the BKCValidatorSet contract, not by accounts"}
+	ErrRewardNonActive = &RevertError{Reason: "can't add reward to a non-active validator", Contract: "SystemReward",
+		Hint: "rewards can only be added to validators of the active set, see `validatorset validators list`"}
+	ErrZeroReward = &RevertError{Reason: "can't add reward with 0 value", Contract: "SystemReward",
+		Hint: "give an amount greater than 0"}
+	ErrRewardUnderfunded = &RevertError{Reason: "can't distribute reward, not enough fund in the account, for some reason...", Contract: "SystemReward",
+		Hint: "the balance of the system reward contract is lower than the rewards, fund it"}
+)
+
+// The require reasons of BKCValidatorSet.sol. Its onlyValidatorInValidatorSet
+// modifier shares the reason of ValidatorPool, ErrNonActiveValidator.
+var (
+	ErrBeforeEndTime = &RevertError{Reason: "can't update before end time", Contract: "BKCValidatorSet",
+		Hint: "the epoch isn't over, see `validatorset end-time`", wait: "epoch ends"}
+)
+
+// Reverts is the catalogue of the require reasons, by reason.
+var Reverts = map[string]*RevertError{}
+
+func init() {
+	for _, r := range []*RevertError{
+		ErrOnlyBlockProducer, ErrAlreadyInit, ErrNotInit,
+		ErrAdminOnly, ErrOnlyValidators, ErrNonActiveValidator, ErrOnlyOtherValidators,
+		ErrOnlyValidatorSetContract, ErrNotUnbonded, ErrNotEnoughStake, ErrZeroTopUp,
+		ErrUnbondInProgress, ErrUnbondJailed, ErrJailInProgress, ErrRemoveInProgress,
+		ErrNotInRemoveQueue, ErrJailNonActive, ErrStakeTooLow, ErrAlreadyRegistered,
+		ErrRemoveNonValidator,
+		ErrStakePoolUnderfunded, ErrDelegateNonValidator, ErrDelegateNotUnbonded, ErrUndelegateTooMuch,
+		ErrOnlyValidatorSet, ErrRewardNonActive, ErrZeroReward, ErrRewardUnderfunded,
+		ErrBeforeEndTime,
+	} {
+		Reverts[r.Reason] = r
+	}
+}
+
+// Explain completes the hint of a revert whose call can be retried later with
+// the time at which the validator leaves the queue, e.g. "validator unjails at
+// 12:04:05, retry after". Other errors are returned unchanged.
+func (c *Client) Explain(ctx context.Context, err error, validator common.Address) error {
+	var e *Error
+	if !errors.As(Classify(err), &e) || e.Revert == nil {
+		return err
+	}
+	var t time.Time
+	switch {
+	case e.Revert.queue != nil:
+		t, _ = c.AtBlock(nil).QueueTime(ctx, *e.Revert.queue, validator)
+	case e.Revert == ErrBeforeEndTime:
+		t, _ = c.AtBlock(nil).EndTime(ctx)
+	}
+	if t.IsZero() {
+		return e
+	}
+	explained := *e
+	explained.Hint = e.Revert.retryHint(t)
+	return &explained
+}
+
+// retryHint tells when the call can be retried, e.g. "validator unjails at 12:04:05, retry after".
+func (r *RevertError) retryHint(t time.Time) string {
+	return fmt.Sprintf("%s at %s, retry after", r.wait, formatTime(t))
+}
+
+// formatTime shows the time of day only when t is today.
+func formatTime(t time.Time) string {
+	now := time.Now()
+	if t.Year() == now.Year() && t.YearDay() == now.YearDay() {
+		return t.Format("15:04:05")
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
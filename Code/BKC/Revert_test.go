@@ -0,0 +1,126 @@
+package bkc
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"syscall"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// errorData is the return data of revert(reason).
+func errorData(t *testing.T, reason string) []byte {
+	typ, err := abi.NewType("string", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packed, err := abi.Arguments{{Type: typ}}.Pack(reason)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(append([]byte{}, errorSelector...), packed...)
+}
+
+// panicData is the return data of Panic(code).
+func panicData(code int64) []byte {
+	return append(append([]byte{}, panicSelector...), common.LeftPadBytes(big.NewInt(code).Bytes(), 32)...)
+}
+
+// dataError is an error of a reverted call as geth reports it, with the return
+// data attached.
+type dataError struct {
+	data []byte
+}
+
+func (e dataError) Error() string          { return "execution reverted" }
+func (e dataError) ErrorCode() int         { return 3 }
+func (e dataError) ErrorData() interface{} { return hexutil.Encode(e.data) }
+
+func TestDecodeRevert(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+		err  bool
+	}{
+		{"empty", nil, "", false},
+		{"Error(string)", errorData(t, "admin only"), "admin only", false},
+		{"empty Error(string)", errorData(t, ""), "", false},
+		{"overflow", panicData(0x11), "panic 0x11: arithmetic overflow or underflow", false},
+		{"division by zero", panicData(0x12), "panic 0x12: division by zero", false},
+		{"out of bounds", panicData(0x32), outOfBounds, false},
+		{"unknown panic code", panicData(0x99), "panic 0x99", false},
+		{"short panic", panicData(0x11)[:20], "", true},
+		{"custom error", []byte{0xde, 0xad, 0xbe, 0xef}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeRevert(tt.data)
+			if (err != nil) != tt.err {
+				t.Fatalf("DecodeRevert(%x) error = %v, want error %v", tt.data, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("DecodeRevert(%x) = %q, want %q", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	known := Errorf(KindUsage, "bad flag")
+	tests := []struct {
+		name   string
+		err    error
+		kind   Kind
+		reason string
+		revert *RevertError
+	}{
+		{"revert data", dataError{errorData(t, ErrUndelegateTooMuch.Reason)}, KindRevert, ErrUndelegateTooMuch.Reason, ErrUndelegateTooMuch},
+		{"revert data of onlyInit", dataError{errorData(t, notInitReason)}, KindNotInitialised, notInitReason, ErrNotInit},
+		{"panic data", dataError{panicData(0x11)}, KindRevert, "panic 0x11: arithmetic overflow or underflow", nil},
+		{"revert without a reason", dataError{nil}, KindRevert, "", nil},
+		{"unknown reason", dataError{errorData(t, "something else")}, KindRevert, "something else", nil},
+		{"geth message", errors.New("execution reverted: admin only"), KindRevert, "admin only", ErrAdminOnly},
+		{"gas estimation", fmt.Errorf("failed to estimate gas needed: %w", errors.New("execution reverted: admin only")), KindRevert, "admin only", ErrAdminOnly},
+		{"ganache message", errors.New("VM Exception while processing transaction: revert admin only"), KindRevert, "admin only", ErrAdminOnly},
+		{"not found", ethereum.NotFound, KindNotFound, "", nil},
+		{"connection refused", fmt.Errorf("dial: %w", syscall.ECONNREFUSED), KindConnection, "", nil},
+		{"unknown", errors.New("something broke"), KindUnknown, "", nil},
+		{"already classified", known, KindUsage, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Classify(tt.err)
+			if got := KindOf(err); got != tt.kind {
+				t.Errorf("kind = %v, want %v", got, tt.kind)
+			}
+			var e *Error
+			if !errors.As(err, &e) {
+				if tt.kind != KindUnknown {
+					t.Fatalf("Classify(%v) = %T, want an *Error", tt.err, err)
+				}
+				return
+			}
+			if e.Reason != tt.reason {
+				t.Errorf("reason = %q, want %q", e.Reason, tt.reason)
+			}
+			if e.Revert != tt.revert {
+				t.Errorf("revert = %v, want %v", e.Revert, tt.revert)
+			}
+			if tt.revert != nil && !errors.Is(err, tt.revert) {
+				t.Errorf("errors.Is(err, %v) = false", tt.revert)
+			}
+		})
+	}
+	if Classify(known) != known {
+		t.Error("Classify wrapped an error that already has a kind")
+	}
+	if Classify(nil) != nil {
+		t.Error("Classify(nil) != nil")
+	}
+}
@@ -0,0 +1,147 @@
+package bkc
+
+import (
+	"context"
+	"math/big"
+	"time"
+	IValidator "win/Code/IValidator"
+	Output "win/Code/Output"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Undelegation is an entry of the unbonding queue of a delegator. Entries that
+// have been paid out stay in the queue with a zero amount.
+type Undelegation struct {
+	Index     int
+	Amount    *big.Int
+	Time      time.Time
+	Validator common.Address
+}
+
+// Delegation is the amount the delegator delegates to the validator, bonded and unbonding.
+func (c *Client) Delegation(ctx context.Context, validator common.Address, delegator common.Address) (*big.Int, error) {
+	return c.StakePool.GetDelegationAmountOfEach(c.callOpts(ctx), validator, delegator)
+}
+
+// BondedDelegation is the bonded part of the delegation.
+func (c *Client) BondedDelegation(ctx context.Context, validator common.Address, delegator common.Address) (*big.Int, error) {
+	return c.StakePool.GetUserDelegationBondedAmountCallable(c.callOpts(ctx), delegator, validator)
+}
+
+// UnbondingDelegation is the part of the delegation waiting in the unbonding queue.
+func (c *Client) UnbondingDelegation(ctx context.Context, validator common.Address, delegator common.Address) (*big.Int, error) {
+	return c.StakePool.GetUserDelegationUnbondingAmountCallable(c.callOpts(ctx), delegator, validator)
+}
+
+// TotalDelegation is the sum of the delegations to the validator, optionally without the unbonding ones.
+func (c *Client) TotalDelegation(ctx context.Context, validator common.Address, excludeUnbonding bool) (*big.Int, error) {
+	if excludeUnbonding {
+		return c.StakePool.GetTotalDelegationExcludeUnbonding(c.callOpts(ctx), validator)
+	}
+	return c.StakePool.GetTotalDelegation(c.callOpts(ctx), validator)
+}
+
+// Delegators lists the delegators of the validator.
+func (c *Client) Delegators(ctx context.Context, validator common.Address) ([]common.Address, error) {
+	return c.StakePool.GetDelegators(c.callOpts(ctx), validator)
+}
+
+// UnbondingQueue lists the undelegations of the delegator.
+func (c *Client) UnbondingQueue(ctx context.Context, delegator common.Address) ([]Undelegation, error) {
+	q, err := c.StakePool.GetUnbondingValue(c.callOpts(ctx), delegator)
+	if err != nil {
+		return nil, err
+	}
+	undelegations := make([]Undelegation, 0, len(q))
+	for i, entry := range q {
+		undelegations = append(undelegations, Undelegation{
+			Index:     i,
+			Amount:    entry.Amount,
+			Time:      time.Unix(entry.Time.Int64(), 0),
+			Validator: entry.Validator,
+		})
+	}
+	return undelegations, nil
+}
+
+// CheckDelegate tells whether delegate would accept the amount for the
+// validator, which must be an unbonded validator of the pool.
+func (c *Client) CheckDelegate(ctx context.Context, validator common.Address, amount *big.Int) error {
+	if amount.Sign() <= 0 {
+		return Errorf(KindUsage, "the amount must be greater than 0")
+	}
+	p, err := c.PoolPosition(ctx, validator)
+	if err != nil {
+		return err
+	}
+	if p == 0 {
+		return predicted(ErrDelegateNonValidator, "%s is not in the validator pool", validator.Hex())
+	}
+	v, err := c.PoolValidator(ctx, p-1)
+	if err != nil {
+		return err
+	}
+	if v.BondStatus != Unbonded {
+		return predicted(ErrDelegateNotUnbonded, "%s is %s, delegations are only accepted while it is unbonded", validator.Hex(), IValidator.BondStatusName(v.BondStatus))
+	}
+	return nil
+}
+
+// CheckUndelegate tells whether undelegate would accept the amount from the
+// delegator. The validator must still be in the pool, whose bond status tells
+// whether the amount is paid out now or goes through the unbonding queue. While
+// it is bonded or unbonding the amount comes out of the bonded delegation, which
+// doesn't include what is unbonding already.
+func (c *Client) CheckUndelegate(ctx context.Context, validator common.Address, delegator common.Address, amount *big.Int) error {
+	if amount.Sign() <= 0 {
+		return Errorf(KindUsage, "the amount must be greater than 0")
+	}
+	d, err := c.Delegation(ctx, validator, delegator)
+	if err != nil {
+		return err
+	}
+	if d.Cmp(amount) < 0 {
+		return predicted(ErrUndelegateTooMuch, "%s delegates %s ether to %s, less than the amount of %s ether", delegator.Hex(), Output.Ether(d), validator.Hex(), Output.Ether(amount))
+	}
+	v, _, err := c.PoolValidatorByAddress(ctx, validator)
+	if err != nil || v.BondStatus == Unbonded {
+		return err
+	}
+	bonded, err := c.BondedDelegation(ctx, validator, delegator)
+	if err != nil {
+		return err
+	}
+	if bonded.Cmp(amount) < 0 {
+		return predicted(ErrUndelegateTooMuch, "the bonded delegation of %s to %s is %s ether, less than the amount of %s ether", delegator.Hex(), validator.Hex(), Output.Ether(bonded), Output.Ether(amount))
+	}
+	return nil
+}
+
+// MatureUndelegations are the entries of the unbonding queue of the delegator
+// that removeUnbondingUserFromUnbondingQueue pays out now, those whose time is
+// before the latest block, and their total. next is the time of the earliest
+// entry that isn't mature yet, zero when there is none.
+func (c *Client) MatureUndelegations(ctx context.Context, delegator common.Address) (mature []Undelegation, total *big.Int, next time.Time, err error) {
+	latest := c.AtBlock(nil)
+	now, err := latest.Now(ctx)
+	if err != nil {
+		return nil, nil, time.Time{}, err
+	}
+	q, err := latest.UnbondingQueue(ctx, delegator)
+	if err != nil {
+		return nil, nil, time.Time{}, err
+	}
+	total = new(big.Int)
+	for _, u := range q {
+		switch {
+		case u.Amount.Sign() == 0:
+		case u.Time.Before(now):
+			mature = append(mature, u)
+			total.Add(total, u.Amount)
+		case next.IsZero() || u.Time.Before(next):
+			next = u.Time
+		}
+	}
+	return mature, total, next, nil
+}
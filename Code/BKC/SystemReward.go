@@ -0,0 +1,45 @@
+package bkc
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Reward is the reward waiting in rewardMapping to be distributed to the validator and its delegators.
+func (c *Client) Reward(ctx context.Context, validator common.Address) (*big.Int, error) {
+	return c.SystemReward.RewardMapping(c.callOpts(ctx), validator)
+}
+
+// RewardBalance is the balance of the system reward contract.
+func (c *Client) RewardBalance(ctx context.Context) (*big.Int, error) {
+	return c.SystemReward.GetBalance(c.callOpts(ctx))
+}
+
+// RewardCreditedPercent is PERCENTAGE_OF_REWARD_KEPT_FOR_MAINTENANCE of
+// SystemReward.sol. Despite its name it is the share of addReward credited to
+// the validator, the rest stays in the contract for maintenance.
+const RewardCreditedPercent = 90
+
+// CreditedReward is the part of an addReward amount credited to rewardMapping.
+func CreditedReward(amount *big.Int) *big.Int {
+	credited := new(big.Int).Mul(amount, big.NewInt(RewardCreditedPercent))
+	return credited.Div(credited, big.NewInt(100))
+}
+
+// CheckAddReward tells whether addReward would accept the amount for the
+// validator, which must be in the active set, and returns the amount credited.
+func (c *Client) CheckAddReward(ctx context.Context, validator common.Address, amount *big.Int) (*big.Int, error) {
+	if amount.Sign() <= 0 {
+		return nil, predicted(ErrZeroReward, "the amount must be greater than 0")
+	}
+	p, err := c.ActivePosition(ctx, validator)
+	if err != nil {
+		return nil, err
+	}
+	if p == 0 {
+		return nil, predicted(ErrRewardNonActive, "%s is not in the active set", validator.Hex())
+	}
+	return CreditedReward(amount), nil
+}
@@ -0,0 +1,47 @@
+package bkc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// FailedError returns the error of a mined transaction whose receipt has a failed
+// status. The call is replayed on the state before its block to get the revert
+// reason, which receipts don't carry. When that state can't be had, on a pruned
+// node or on the simulated backend which only serves the latest block, the call
+// is replayed on the latest state instead.
+func (c *Client) FailedError(ctx context.Context, tx *types.Transaction, from common.Address, receipt *types.Receipt) error {
+	msg := ethereum.CallMsg{From: from, To: tx.To(), Gas: tx.Gas(), Value: tx.Value(), Data: tx.Data()}
+	_, err := c.backend.CallContract(ctx, msg, new(big.Int).Sub(receipt.BlockNumber, big.NewInt(1)))
+	if _, reverted := revertReason(err); err != nil && !reverted {
+		_, err = c.backend.CallContract(ctx, msg, nil)
+	}
+	failed := fmt.Errorf("transaction %s reverted in block %v", tx.Hash().Hex(), receipt.BlockNumber)
+	if err == nil {
+		// the state the transaction ran on isn't available, e.g. it ran out of gas
+		return &Error{Kind: KindRevert, Err: failed}
+	}
+	if e, ok := Classify(err).(*Error); ok {
+		explained := *e
+		explained.Err = fmt.Errorf("%v: %w", failed, err)
+		return &explained
+	}
+	return fmt.Errorf("%v: %w", failed, err)
+}
+
+// Simulate runs the transaction on the latest state with eth_call, then estimates
+// its gas, without sending it. A revert is returned as the error of the call,
+// which carries its reason.
+func (c *Client) Simulate(ctx context.Context, tx *types.Transaction, from common.Address) (uint64, error) {
+	msg := ethereum.CallMsg{From: from, To: tx.To(), Gas: tx.Gas(), Value: tx.Value(), Data: tx.Data()}
+	if _, err := c.backend.CallContract(ctx, msg, nil); err != nil {
+		return 0, err
+	}
+	msg.Gas = 0
+	return c.backend.EstimateGas(ctx, msg)
+}
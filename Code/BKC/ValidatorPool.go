@@ -0,0 +1,274 @@
+package bkc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+	IValidator "win/Code/IValidator"
+	Output "win/Code/Output"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Queue names one of the per validator queues of the validator pool.
+type Queue int
+
+const (
+	UnbondQueue Queue = iota
+	JailQueue
+	RemoveQueue
+)
+
+func (q Queue) String() string {
+	switch q {
+	case UnbondQueue:
+		return "unbond"
+	case JailQueue:
+		return "jail"
+	case RemoveQueue:
+		return "remove"
+	}
+	return "unknown"
+}
+
+// NumberOfPoolValidators is the number of validators in the pool.
+func (c *Client) NumberOfPoolValidators(ctx context.Context) (int, error) {
+	n, err := c.ValidatorPool.NumberOfValidator(c.callOpts(ctx))
+	if err != nil {
+		return 0, err
+	}
+	return int(n.Int64()), nil
+}
+
+// PoolValidator returns the validator at index (starting at 0) of the pool.
+func (c *Client) PoolValidator(ctx context.Context, index int) (Validator, error) {
+	v, err := c.ValidatorPool.Validators(c.callOpts(ctx), big.NewInt(int64(index)))
+	if err != nil {
+		return Validator{}, indexError(err, "can't read validator %d of the pool", index)
+	}
+	return IValidator.Validator(v), nil
+}
+
+// PoolValidators returns all the validators of the pool.
+func (c *Client) PoolValidators(ctx context.Context) ([]Validator, error) {
+	n, err := c.NumberOfPoolValidators(ctx)
+	if err != nil {
+		return nil, err
+	}
+	validators := make([]Validator, 0, n)
+	for i := 0; i < n; i++ {
+		v, err := c.PoolValidator(ctx, i)
+		if err != nil {
+			return nil, err
+		}
+		validators = append(validators, v)
+	}
+	return validators, nil
+}
+
+// PoolPosition is the position (starting at 1) of a validator in the pool, 0 when it isn't in the pool.
+func (c *Client) PoolPosition(ctx context.Context, validator common.Address) (int, error) {
+	p, err := c.ValidatorPool.ValidatorsMap(c.callOpts(ctx), validator)
+	if err != nil {
+		return 0, err
+	}
+	return int(p.Int64()), nil
+}
+
+// PoolValidatorByAddress returns a validator of the pool and its index.
+func (c *Client) PoolValidatorByAddress(ctx context.Context, validator common.Address) (Validator, int, error) {
+	p, err := c.PoolPosition(ctx, validator)
+	if err != nil {
+		return Validator{}, 0, err
+	}
+	if p == 0 {
+		return Validator{}, 0, Errorf(KindNotFound, "the validator address %s is not in the validator pool", validator.Hex())
+	}
+	v, err := c.PoolValidator(ctx, p-1)
+	return v, p - 1, err
+}
+
+// QueueTime is the time at which the validator leaves the queue. It is the zero
+// time when the validator isn't queued or isn't a validator.
+func (c *Client) QueueTime(ctx context.Context, queue Queue, validator common.Address) (time.Time, error) {
+	opts := c.callOpts(ctx)
+	var t *big.Int
+	var err error
+	switch queue {
+	case UnbondQueue:
+		t, err = c.ValidatorPool.ValidatorUnBondQueue(opts, validator)
+	case JailQueue:
+		t, err = c.ValidatorPool.ValidatorJailQueue(opts, validator)
+	case RemoveQueue:
+		t, err = c.ValidatorPool.ValidatorRemoveQueue(opts, validator)
+	default:
+		return time.Time{}, fmt.Errorf("unknown queue %d", queue)
+	}
+	if err != nil || t.Sign() == 0 {
+		return time.Time{}, err
+	}
+	return time.Unix(t.Int64(), 0), nil
+}
+
+// TotalPowerExcludeUnbonding is the stake of the validator plus its delegations that aren't unbonding.
+func (c *Client) TotalPowerExcludeUnbonding(ctx context.Context, validator common.Address) (*big.Int, error) {
+	return c.ValidatorPool.GetTotalPowerExcludeUnbonding(c.callOpts(ctx), validator)
+}
+
+// MinValidatorStake is MIN_VALIDATOR_STAKE_AMOUNT of ValidatorPool.sol, 10 ether.
+// A validator whose stake drops below it is removed from the pool.
+var MinValidatorStake = new(big.Int).Mul(big.NewInt(10), big.NewInt(params.Ether))
+
+// Admin is the ADMIN account of ValidatorPool.sol, the only one allowed to jail validators.
+var Admin = common.HexToAddress("0x090fb1c3d66303358806836DF2B5b44fcd3e582f")
+
+// JailSlash is what jailValidator takes from the stake of the validator, 5 ether.
+var JailSlash = new(big.Int).Mul(big.NewInt(5), big.NewInt(params.Ether))
+
+// CheckRegister tells whether registerValidator would accept the validator with the stake.
+func (c *Client) CheckRegister(ctx context.Context, validator common.Address, stake *big.Int) error {
+	if stake.Cmp(MinValidatorStake) < 0 {
+		return predicted(ErrStakeTooLow, "the stake of %s ether is below the minimum of %s ether", Output.Ether(stake), Output.Ether(MinValidatorStake))
+	}
+	p, err := c.PoolPosition(ctx, validator)
+	if err != nil {
+		return err
+	}
+	if p > 0 {
+		return predicted(ErrAlreadyRegistered, "%s is already validator %d of the pool", validator.Hex(), p-1)
+	}
+	return nil
+}
+
+// CheckNotBonded returns the validator, which must be in the pool and unbonded
+// as the onlyNotBonded modifier requires.
+func (c *Client) CheckNotBonded(ctx context.Context, validator common.Address) (Validator, error) {
+	v, _, err := c.PoolValidatorByAddress(ctx, validator)
+	if err != nil {
+		return v, err
+	}
+	if v.BondStatus != Unbonded {
+		return v, predicted(ErrNotUnbonded, "%s is %s, it must be unbonded", validator.Hex(), IValidator.BondStatusName(v.BondStatus))
+	}
+	return v, nil
+}
+
+// CheckTopUp tells whether validatorTopUp would accept the amount from the validator.
+func (c *Client) CheckTopUp(ctx context.Context, validator common.Address, amount *big.Int) error {
+	if amount.Sign() <= 0 {
+		return predicted(ErrZeroTopUp, "the amount must be greater than 0")
+	}
+	_, err := c.CheckNotBonded(ctx, validator)
+	return err
+}
+
+// CheckWithdraw tells whether withdrawFund would accept the amount from the
+// validator and returns the stake left after it. When that is below
+// MinValidatorStake, reEvaluateValidator removes the validator from the pool.
+func (c *Client) CheckWithdraw(ctx context.Context, validator common.Address, amount *big.Int) (*big.Int, error) {
+	v, err := c.CheckNotBonded(ctx, validator)
+	if err != nil {
+		return nil, err
+	}
+	if v.StakeAmount.Cmp(amount) <= 0 {
+		return nil, predicted(ErrNotEnoughStake, "the stake of %s ether must be greater than the amount of %s ether", Output.Ether(v.StakeAmount), Output.Ether(amount))
+	}
+	return new(big.Int).Sub(v.StakeAmount, amount), nil
+}
+
+// CheckRemove tells whether validatorRemove would accept the validator.
+func (c *Client) CheckRemove(ctx context.Context, validator common.Address) error {
+	p, err := c.PoolPosition(ctx, validator)
+	if err != nil {
+		return err
+	}
+	if p == 0 {
+		return predicted(ErrRemoveNonValidator, "%s is not in the validator pool", validator.Hex())
+	}
+	return nil
+}
+
+// CheckJail tells whether jailValidator would accept the validator from signer,
+// which must be the admin, and returns the stake of the validator and the stake
+// left after the slash. When that is below MinValidatorStake, reEvaluateValidator
+// removes the validator from the pool along with all its delegations.
+func (c *Client) CheckJail(ctx context.Context, signer common.Address, validator common.Address) (stake *big.Int, left *big.Int, err error) {
+	if signer != Admin {
+		return nil, nil, predicted(ErrAdminOnly, "%s is not the admin %s", signer.Hex(), Admin.Hex())
+	}
+	p, err := c.ActivePosition(ctx, validator)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p == 0 {
+		return nil, nil, predicted(ErrJailNonActive, "%s is not in the active set", validator.Hex())
+	}
+	v, _, err := c.PoolValidatorByAddress(ctx, validator)
+	if err != nil {
+		return nil, nil, err
+	}
+	if v.StakeAmount.Cmp(JailSlash) < 0 {
+		return nil, nil, Errorf(KindRevert, "the stake of %s ether is less than the slash of %s ether, jailValidator would revert", Output.Ether(v.StakeAmount), Output.Ether(JailSlash))
+	}
+	return v.StakeAmount, new(big.Int).Sub(v.StakeAmount, JailSlash), nil
+}
+
+// CheckLeaveQueue tells whether the validator can leave the queue now, with
+// removeUnBondingValidatorFromQueue, removeJailValidatorFromQueue or
+// removeRemovingValidatorFromQueue, and returns the time of the queue. The
+// contracts let it leave once the latest block is past that time. The error
+// tells how long is left when it is too early.
+func (c *Client) CheckLeaveQueue(ctx context.Context, queue Queue, validator common.Address) (time.Time, error) {
+	latest := c.AtBlock(nil)
+	p, err := latest.PoolPosition(ctx, validator)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if p == 0 {
+		return time.Time{}, predicted(ErrOnlyValidators, "%s is not in the validator pool", validator.Hex())
+	}
+	t, err := latest.QueueTime(ctx, queue, validator)
+	if err != nil {
+		return t, err
+	}
+	if t.IsZero() {
+		// leaving the unbond or jail queue without being in it would still
+		// change the bond status, so it is refused as well
+		if queue == RemoveQueue {
+			return t, predicted(ErrNotInRemoveQueue, "%s is not in the remove queue", validator.Hex())
+		}
+		return t, Errorf(KindNotFound, "%s is not in the %s queue", validator.Hex(), queue)
+	}
+	now, err := latest.Now(ctx)
+	if err != nil {
+		return t, err
+	}
+	if !now.After(t) {
+		r := map[Queue]*RevertError{UnbondQueue: ErrUnbondInProgress, JailQueue: ErrJailInProgress, RemoveQueue: ErrRemoveInProgress}[queue]
+		err := predicted(r, "%s is in the %s queue until %s, %s left", validator.Hex(), queue, t.Format(time.RFC3339), t.Sub(now)+time.Second)
+		err.(*Error).Hint = r.retryHint(t)
+		return t, err
+	}
+	if queue == UnbondQueue {
+		jail, err := latest.QueueTime(ctx, JailQueue, validator)
+		if err != nil {
+			return t, err
+		}
+		if !jail.IsZero() {
+			return t, predicted(ErrUnbondJailed, "%s is jailed until %s", validator.Hex(), jail.Format(time.RFC3339))
+		}
+	}
+	return t, nil
+}
+
+// Now is the time of the latest block, which is what block.timestamp compares
+// the queue times with.
+func (c *Client) Now(ctx context.Context) (time.Time, error) {
+	header, err := c.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(header.Time), 0), nil
+}
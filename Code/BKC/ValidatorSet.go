@@ -0,0 +1,97 @@
+package bkc
+
+import (
+	"context"
+	"math/big"
+	"time"
+	IValidator "win/Code/IValidator"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// NumberOfActiveValidators is the number of validators in the active set.
+func (c *Client) NumberOfActiveValidators(ctx context.Context) (int, error) {
+	n, err := c.ValidatorSet.NumberOfValidators(c.callOpts(ctx))
+	if err != nil {
+		return 0, err
+	}
+	return int(n.Int64()), nil
+}
+
+// ActiveValidator returns the validator at index (starting at 0) of the active set.
+func (c *Client) ActiveValidator(ctx context.Context, index int) (Validator, error) {
+	v, err := c.ValidatorSet.CurrentValidatorSet(c.callOpts(ctx), big.NewInt(int64(index)))
+	if err != nil {
+		return Validator{}, indexError(err, "can't read validator %d of the active set", index)
+	}
+	return IValidator.Validator(v), nil
+}
+
+// ActiveValidators returns the active set.
+func (c *Client) ActiveValidators(ctx context.Context) ([]Validator, error) {
+	vlds, err := c.ValidatorSet.GetValidators(c.callOpts(ctx))
+	if err != nil {
+		return nil, err
+	}
+	validators := make([]Validator, 0, len(vlds))
+	for _, v := range vlds {
+		validators = append(validators, IValidator.Validator(v))
+	}
+	return validators, nil
+}
+
+// ActivePosition is the position (starting at 1) of a validator in the active set, 0 when it isn't active.
+func (c *Client) ActivePosition(ctx context.Context, validator common.Address) (int, error) {
+	p, err := c.ValidatorSet.CurrentValidatorSetMap(c.callOpts(ctx), validator)
+	if err != nil {
+		return 0, err
+	}
+	return int(p.Int64()), nil
+}
+
+// IsActive tells whether the address is in the active set.
+func (c *Client) IsActive(ctx context.Context, validator common.Address) (bool, error) {
+	p, err := c.ActivePosition(ctx, validator)
+	return p > 0, err
+}
+
+// ActiveValidatorByAddress returns a validator of the active set and its index.
+func (c *Client) ActiveValidatorByAddress(ctx context.Context, validator common.Address) (Validator, int, error) {
+	p, err := c.ActivePosition(ctx, validator)
+	if err != nil {
+		return Validator{}, 0, err
+	}
+	if p == 0 {
+		return Validator{}, 0, Errorf(KindNotFound, "the validator address %s is not in the active set", validator.Hex())
+	}
+	v, err := c.ActiveValidator(ctx, p-1)
+	return v, p - 1, err
+}
+
+// EndTime is the end of the current epoch, after which the set can be updated.
+func (c *Client) EndTime(ctx context.Context) (time.Time, error) {
+	t, err := c.ValidatorSet.EndTime(c.callOpts(ctx))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(t.Int64(), 0), nil
+}
+
+// CheckUpdate tells whether the epoch is over, which updateValidatorSet doesn't
+// enforce itself, and returns its end time. The error tells how long is left
+// when it is too early.
+func (c *Client) CheckUpdate(ctx context.Context) (time.Time, error) {
+	latest := c.AtBlock(nil)
+	end, err := latest.EndTime(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	now, err := latest.Now(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if now.Before(end) {
+		return end, predicted(ErrBeforeEndTime, "the epoch ends at %s, %s left", end.Format(time.RFC3339), end.Sub(now))
+	}
+	return end, nil
+}
@@ -0,0 +1,90 @@
+package block
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// HeaderReader is the part of ethclient.Client needed to look up blocks.
+type HeaderReader interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error)
+}
+
+// Resolve turns a block number, a block hash or an RFC3339 timestamp into a block
+// number. An empty spec or "latest" resolves to nil, the latest block.
+func Resolve(ctx context.Context, reader HeaderReader, spec string) (*big.Int, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == "latest" {
+		return nil, nil
+	}
+	if len(spec) == 2+2*common.HashLength && strings.HasPrefix(spec, "0x") {
+		hash := common.HexToHash(spec)
+		header, err := reader.HeaderByHash(ctx, hash)
+		if err != nil {
+			return nil, fmt.Errorf("can't find block %s: %w", hash.Hex(), err)
+		}
+		return header.Number, nil
+	}
+	if n, ok := new(big.Int).SetString(spec, 10); ok {
+		if n.Sign() < 0 {
+			return nil, fmt.Errorf("invalid block %q, block numbers can't be negative", spec)
+		}
+		return n, nil
+	}
+	if n, err := hexutil.DecodeBig(spec); err == nil {
+		return n, nil
+	}
+	t, err := time.Parse(time.RFC3339, spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid block %q, expected a number, a block hash or an RFC3339 timestamp", spec)
+	}
+	return AtTime(ctx, reader, t)
+}
+
+// AtTime returns the number of the last block mined at or before t, found by
+// binary search over the block timestamps. A time in the future is refused
+// rather than resolved to the latest block, which it may not end up being.
+func AtTime(ctx context.Context, reader HeaderReader, t time.Time) (*big.Int, error) {
+	if now := time.Now(); t.After(now) {
+		return nil, fmt.Errorf("%s is in the future, it is %s now", t.Format(time.RFC3339), now.Format(time.RFC3339))
+	}
+	latest, err := reader.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	target := uint64(t.Unix())
+	if t.Unix() >= 0 && latest.Time <= target {
+		return latest.Number, nil
+	}
+	genesis, err := reader.HeaderByNumber(ctx, big.NewInt(0))
+	if err != nil {
+		return nil, err
+	}
+	if t.Unix() < 0 || genesis.Time > target {
+		return nil, fmt.Errorf("%s is before the first block of the chain (%s)", t.Format(time.RFC3339), time.Unix(int64(genesis.Time), 0).Format(time.RFC3339))
+	}
+
+	// invariant: block lo is at or before target, block hi is after it
+	lo, hi := uint64(0), latest.Number.Uint64()
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		header, err := reader.HeaderByNumber(ctx, new(big.Int).SetUint64(mid))
+		if err != nil {
+			return nil, err
+		}
+		if header.Time <= target {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return new(big.Int).SetUint64(lo), nil
+}
@@ -0,0 +1,119 @@
+package block
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+// newChain is a simulated chain of the given number of blocks after genesis.
+// The simulated backend mines a block 10 seconds after its parent, from a
+// genesis at time 0.
+func newChain(t *testing.T, blocks int) *backends.SimulatedBackend {
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{}, 8000000)
+	t.Cleanup(func() { sim.Close() })
+	for i := 0; i < blocks; i++ {
+		sim.Commit()
+	}
+	return sim
+}
+
+func TestAtTime(t *testing.T) {
+	ctx := context.Background()
+	sim := newChain(t, 20)
+	timeOf := func(n int64) int64 {
+		h, err := sim.HeaderByNumber(ctx, big.NewInt(n))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return int64(h.Time)
+	}
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want int64
+	}{
+		{"genesis", time.Unix(timeOf(0), 0), 0},
+		{"first block", time.Unix(timeOf(1), 0), 1},
+		{"between two blocks", time.Unix(timeOf(7)+3, 0), 7},
+		{"just before a block", time.Unix(timeOf(8)-1, 0), 7},
+		{"next to last block", time.Unix(timeOf(19), 0), 19},
+		{"latest block", time.Unix(timeOf(20), 0), 20},
+		{"after the latest block", time.Unix(timeOf(20)+5, 0), 20},
+		{"now", time.Now(), 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AtTime(ctx, sim, tt.t)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Int64() != tt.want {
+				t.Errorf("AtTime(%v) = %v, want %d", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAtTimeRefused(t *testing.T) {
+	ctx := context.Background()
+	sim := newChain(t, 5)
+	tests := []struct {
+		name string
+		t    time.Time
+		want string
+	}{
+		{"before genesis", time.Unix(-10, 0), "before the first block"},
+		{"in the future", time.Now().Add(time.Hour), "in the future"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AtTime(ctx, sim, tt.t)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("AtTime(%v) = %v, %v, want an error containing %q", tt.t, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	ctx := context.Background()
+	sim := newChain(t, 5)
+	h, err := sim.HeaderByNumber(ctx, big.NewInt(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		spec string
+		want *big.Int
+	}{
+		{"", nil},
+		{"latest", nil},
+		{"4", big.NewInt(4)},
+		{"0x2", big.NewInt(2)},
+		{h.Hash().Hex(), big.NewInt(3)},
+		{time.Unix(int64(h.Time)+1, 0).UTC().Format(time.RFC3339), big.NewInt(3)},
+	}
+	for _, tt := range tests {
+		got, err := Resolve(ctx, sim, tt.spec)
+		if err != nil {
+			t.Errorf("Resolve(%q): %v", tt.spec, err)
+			continue
+		}
+		if (got == nil) != (tt.want == nil) || (got != nil && got.Cmp(tt.want) != 0) {
+			t.Errorf("Resolve(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+	for _, spec := range []string{"yesterday", "-5", "-0x1"} {
+		if got, err := Resolve(ctx, sim, spec); err == nil || !strings.Contains(err.Error(), "invalid block") {
+			t.Errorf("Resolve(%q) = %v, %v, want an invalid block error", spec, got, err)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package discover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Storage slots of the private address fields. Every contract inherits System
+// (alreadyInit, slot 0) and IBond (unbondingPeriod, slot 1) before its own fields;
+// constants take no storage.
+const (
+	ValidatorSetValidatorPoolSlot = 9  // BKCValidatorSet._validatorPoolAddress
+	ValidatorSetSystemRewardSlot  = 10 // BKCValidatorSet._systemRewardAddress
+
+	ValidatorPoolValidatorSetSlot = 6 // ValidatorPool._BKCValidatorSetAddress
+	ValidatorPoolStakePoolSlot    = 7 // ValidatorPool._StakePoolAddress
+
+	StakePoolValidatorPoolSlot = 5 // StakePool._ValidatorPoolAddress
+
+	SystemRewardValidatorSetSlot  = 3 // SystemReward._BKCValidatorSetAddress
+	SystemRewardStakePoolSlot     = 4 // SystemReward._StakePoolAddress
+	SystemRewardValidatorPoolSlot = 5 // SystemReward._ValidatorPoolAddress
+)
+
+// ErrNotInit is wrapped by the error of Discover when an address field is still
+// zero, which is the case before init is called.
+var ErrNotInit = errors.New("not init yet")
+
+// StorageReader is the part of ethclient.Client needed to read contract storage.
+type StorageReader interface {
+	StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error)
+}
+
+// Addresses is a full set of the four contracts.
+type Addresses struct {
+	ValidatorSet  common.Address
+	StakePool     common.Address
+	SystemReward  common.Address
+	ValidatorPool common.Address
+}
+
+// ReadAddress reads the address stored at slot of contract. Addresses are right aligned in their slot.
+func ReadAddress(ctx context.Context, reader StorageReader, contract common.Address, slot int64, block *big.Int) (common.Address, error) {
+	value, err := reader.StorageAt(ctx, contract, common.BigToHash(big.NewInt(slot)), block)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return common.BytesToAddress(value), nil
+}
+
+// Discover starts from the BKCValidatorSet address and reads the addresses of the
+// other three contracts from storage. The back references held by the other
+// contracts are checked as well, so a mixed-up deployment is reported as an error.
+func Discover(ctx context.Context, reader StorageReader, validatorSet common.Address, block *big.Int) (Addresses, error) {
+	a := Addresses{ValidatorSet: validatorSet}
+	var err error
+
+	if a.ValidatorPool, err = readNonZero(ctx, reader, validatorSet, ValidatorSetValidatorPoolSlot, block, "BKCValidatorSet", "validator pool"); err != nil {
+		return a, err
+	}
+	if a.SystemReward, err = readNonZero(ctx, reader, validatorSet, ValidatorSetSystemRewardSlot, block, "BKCValidatorSet", "system reward"); err != nil {
+		return a, err
+	}
+	if a.StakePool, err = readNonZero(ctx, reader, a.ValidatorPool, ValidatorPoolStakePoolSlot, block, "ValidatorPool", "stake pool"); err != nil {
+		return a, err
+	}
+	return a, Verify(ctx, reader, a, block)
+}
+
+// Verify checks that the contracts of a point at each other.
+func Verify(ctx context.Context, reader StorageReader, a Addresses, block *big.Int) error {
+	checks := []struct {
+		contract common.Address
+		name     string
+		slot     int64
+		want     common.Address
+		field    string
+	}{
+		{a.ValidatorSet, "BKCValidatorSet", ValidatorSetValidatorPoolSlot, a.ValidatorPool, "validator pool"},
+		{a.ValidatorSet, "BKCValidatorSet", ValidatorSetSystemRewardSlot, a.SystemReward, "system reward"},
+		{a.ValidatorPool, "ValidatorPool", ValidatorPoolValidatorSetSlot, a.ValidatorSet, "validator set"},
+		{a.ValidatorPool, "ValidatorPool", ValidatorPoolStakePoolSlot, a.StakePool, "stake pool"},
+		{a.StakePool, "StakePool", StakePoolValidatorPoolSlot, a.ValidatorPool, "validator pool"},
+		{a.SystemReward, "SystemReward", SystemRewardValidatorSetSlot, a.ValidatorSet, "validator set"},
+		{a.SystemReward, "SystemReward", SystemRewardStakePoolSlot, a.StakePool, "stake pool"},
+		{a.SystemReward, "SystemReward", SystemRewardValidatorPoolSlot, a.ValidatorPool, "validator pool"},
+	}
+	for _, c := range checks {
+		got, err := ReadAddress(ctx, reader, c.contract, c.slot, block)
+		if err != nil {
+			return err
+		}
+		if got != c.want {
+			return fmt.Errorf("%s at %s points at %s as its %s contract, expected %s", c.name, c.contract.Hex(), got.Hex(), c.field, c.want.Hex())
+		}
+	}
+	return nil
+}
+
+func readNonZero(ctx context.Context, reader StorageReader, contract common.Address, slot int64, block *big.Int, name string, field string) (common.Address, error) {
+	addr, err := ReadAddress(ctx, reader, contract, slot, block)
+	if err != nil {
+		return addr, err
+	}
+	if addr == (common.Address{}) {
+		return addr, fmt.Errorf("%s at %s has no %s address, the contract is not a %s or is %w", name, contract.Hex(), field, name, ErrNotInit)
+	}
+	return addr, nil
+}
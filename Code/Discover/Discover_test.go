@@ -0,0 +1,42 @@
+package discover_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	discover "win/Code/Discover"
+	simulated "win/Code/Simulated"
+	"win/abi/validatorset"
+)
+
+func TestDiscover(t *testing.T) {
+	ctx := context.Background()
+	chain, err := simulated.New(ctx, simulated.DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+
+	got, err := discover.Discover(ctx, chain, chain.Addresses.ValidatorSet, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != chain.Addresses {
+		t.Errorf("Discover = %+v, deployed %+v", got, chain.Addresses)
+	}
+
+	mixed := chain.Addresses
+	mixed.StakePool, mixed.SystemReward = mixed.SystemReward, mixed.StakePool
+	if err := discover.Verify(ctx, chain, mixed, nil); err == nil {
+		t.Error("Verify accepted the stake pool and system reward swapped")
+	}
+
+	// a validator set that isn't initialised has no addresses yet
+	addr, _, _, err := validatorset.DeployValidatorset(chain.Deployer.TransactOpts(ctx), chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := discover.Discover(ctx, chain, addr, nil); !errors.Is(err, discover.ErrNotInit) {
+		t.Errorf("Discover of a contract without init = %v, want %v", err, discover.ErrNotInit)
+	}
+}
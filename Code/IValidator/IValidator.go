@@ -3,6 +3,7 @@ package IValidator
 import (
 	"fmt"
 	"math/big"
+	Output "win/Code/Output"
 
 	"github.com/ethereum/go-ethereum/common"
 )
@@ -14,10 +15,35 @@ type Validator struct {
 	IsJail           bool
 }
 
-func PrintValidator(v Validator) {
-	BondStatus := []string{"BONDED", "UNBONDING", "UNBONDED"}
-	fmt.Println("Consensus Address:", v.ConsensusAddress)
-	fmt.Println("Stake Amount:", v.StakeAmount)
-	fmt.Println("Bond Status:", BondStatus[v.BondStatus])
-	fmt.Println("Is Jail:", v.IsJail)
+// BondStatusNames are the names of the BondStatus enum of IBond.sol.
+var BondStatusNames = []string{"BONDED", "UNBONDING", "UNBONDED"}
+
+// BondStatusName returns the name of a BondStatus value.
+func BondStatusName(b uint8) string {
+	if int(b) < len(BondStatusNames) {
+		return BondStatusNames[b]
+	}
+	return fmt.Sprintf("UNKNOWN(%d)", b)
+}
+
+// View is the output form of a validator. Index is its position (starting at 0)
+// in the array it was read from.
+type View struct {
+	Index            int    `json:"index" yaml:"index"`
+	ConsensusAddress string `json:"consensus_address" yaml:"consensus_address"`
+	StakeWei         string `json:"stake_wei" yaml:"stake_wei"`
+	StakeEther       string `json:"stake_ether" yaml:"stake_ether"`
+	BondStatus       string `json:"bond_status" yaml:"bond_status"`
+	IsJail           bool   `json:"is_jail" yaml:"is_jail"`
+}
+
+func NewView(index int, v Validator) View {
+	return View{
+		Index:            index,
+		ConsensusAddress: v.ConsensusAddress.Hex(),
+		StakeWei:         Output.Wei(v.StakeAmount),
+		StakeEther:       Output.Ether(v.StakeAmount),
+		BondStatus:       BondStatusName(v.BondStatus),
+		IsJail:           v.IsJail,
+	}
 }
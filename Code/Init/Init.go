@@ -1,21 +1,7 @@
 package init
 
-import (
-	"fmt"
-	"log"
-
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
-	"github.com/ethereum/go-ethereum/ethclient"
-)
-
-func AlreadyInit(client ethclient.Client, instance interface {
-	AlreadyInit(b *bind.CallOpts) (bool, error)
-}, name string) {
-	alreadyInit, err := instance.AlreadyInit(&bind.CallOpts{})
-	if err != nil {
-		log.Fatal(err)
-	}
-	fmt.Println()
-	fmt.Println("The contract", name+"'s", "AlreadyInit", "field is", alreadyInit)
-	fmt.Println()
+// Status is the output form of the alreadyInit field of a contract.
+type Status struct {
+	Contract    string `json:"contract" yaml:"contract"`
+	AlreadyInit bool   `json:"already_init" yaml:"already_init"`
 }
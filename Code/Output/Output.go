@@ -0,0 +1,202 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v2"
+)
+
+// The formats accepted by --output.
+const (
+	Table = "table"
+	JSON  = "json"
+	YAML  = "yaml"
+)
+
+// Formats lists the supported output formats.
+var Formats = []string{Table, JSON, YAML}
+
+var weiPerEther = big.NewInt(1e18)
+
+// Ether formats a wei amount in ether without losing precision, e.g. 10.5 for 10500000000000000000.
+func Ether(wei *big.Int) string {
+	if wei == nil {
+		return "0"
+	}
+	abs := new(big.Int).Abs(wei)
+	whole, frac := new(big.Int).QuoRem(abs, weiPerEther, new(big.Int))
+	s := whole.String()
+	if frac.Sign() != 0 {
+		s += "." + strings.TrimRight(fmt.Sprintf("%018s", frac.String()), "0")
+	}
+	if wei.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// ParseEther parses an amount in ether, e.g. 10.5, into wei. Amounts with more
+// than 18 decimals or that are negative are rejected.
+func ParseEther(amount string) (*big.Int, error) {
+	amount = strings.TrimSpace(amount)
+	whole, frac := amount, ""
+	if i := strings.Index(amount, "."); i >= 0 {
+		whole, frac = amount[:i], amount[i+1:]
+	}
+	if len(frac) > 18 || whole+frac == "" || strings.HasPrefix(whole, "-") || strings.HasPrefix(whole, "+") {
+		return nil, fmt.Errorf("invalid ether amount %q", amount)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	wei, ok := new(big.Int).SetString(whole+frac+strings.Repeat("0", 18-len(frac)), 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid ether amount %q", amount)
+	}
+	return wei, nil
+}
+
+// Wei formats a wei amount as a decimal string, so that JSON consumers don't round it.
+func Wei(wei *big.Int) string {
+	if wei == nil {
+		return "0"
+	}
+	return wei.String()
+}
+
+// Render writes v in the given format. Results are structs, or slices of structs,
+// whose json and yaml tags name the fields. In the table format a struct is shown
+// as one field per line and a slice as one row per element.
+func Render(w io.Writer, format string, v interface{}) error {
+	switch format {
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case YAML:
+		out, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(out)
+		return err
+	case Table, "":
+		return renderTable(w, v)
+	}
+	return fmt.Errorf("unknown output format %q, expected one of %s", format, strings.Join(Formats, ", "))
+}
+
+func renderTable(w io.Writer, v interface{}) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		elem := rv.Type().Elem()
+		for elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+		if elem.Kind() != reflect.Struct {
+			for i := 0; i < rv.Len(); i++ {
+				fmt.Fprintln(tw, cell(rv.Index(i)))
+			}
+			break
+		}
+		fmt.Fprintln(tw, strings.Join(headers(elem), "\t"))
+		for i := 0; i < rv.Len(); i++ {
+			row := reflect.Indirect(rv.Index(i))
+			cells := []string{}
+			for j := 0; j < row.NumField(); j++ {
+				if _, ok := fieldName(elem.Field(j)); ok {
+					cells = append(cells, cell(row.Field(j)))
+				}
+			}
+			fmt.Fprintln(tw, strings.Join(cells, "\t"))
+		}
+	case reflect.Struct:
+		nested := []int{}
+		for j := 0; j < rv.NumField(); j++ {
+			name, ok := fieldName(rv.Type().Field(j))
+			if !ok {
+				continue
+			}
+			if isNested(rv.Field(j)) {
+				nested = append(nested, j)
+				continue
+			}
+			fmt.Fprintf(tw, "%s:\t%s\n", name, cell(rv.Field(j)))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		// structs and slices of structs follow as tables of their own
+		for _, j := range nested {
+			if f := rv.Field(j); f.Kind() == reflect.Ptr && f.IsNil() {
+				continue
+			}
+			name, _ := fieldName(rv.Type().Field(j))
+			fmt.Fprintf(w, "\n%s:\n", name)
+			if err := renderTable(w, rv.Field(j).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		fmt.Fprintln(tw, cell(rv))
+	}
+	return tw.Flush()
+}
+
+// isNested tells whether v is a struct or a slice of structs, which the table
+// format shows apart from the other fields.
+func isNested(v reflect.Value) bool {
+	t := v.Type()
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+func headers(t reflect.Type) []string {
+	names := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		if name, ok := fieldName(t.Field(i)); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// fieldName turns the json tag of f into a column header, e.g. stake_ether becomes STAKE ETHER.
+func fieldName(f reflect.StructField) (string, bool) {
+	if f.PkgPath != "" {
+		return "", false
+	}
+	tag := strings.Split(f.Tag.Get("json"), ",")[0]
+	if tag == "-" {
+		return "", false
+	}
+	if tag == "" {
+		tag = f.Name
+	}
+	return strings.ToUpper(strings.ReplaceAll(tag, "_", " ")), true
+}
+
+func cell(v reflect.Value) string {
+	if v.Kind() == reflect.Slice {
+		parts := []string{}
+		for i := 0; i < v.Len(); i++ {
+			parts = append(parts, cell(v.Index(i)))
+		}
+		return strings.Join(parts, ", ")
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,87 @@
+package output
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestParseEther(t *testing.T) {
+	tests := []struct {
+		amount string
+		want   string // wei, empty when the amount is refused
+	}{
+		{"0", "0"},
+		{"1", "1000000000000000000"},
+		{"0.5", "500000000000000000"},
+		{".5", "500000000000000000"},
+		{"10.", "10000000000000000000"},
+		{" 2.25 ", "2250000000000000000"},
+		{"0.000000000000000001", "1"},
+		{"123456789.123456789123456789", "123456789123456789123456789"},
+		{"0.0000000000000000001", ""},
+		{"1e-18", ""},
+		{"1e18", ""},
+		{"-1", ""},
+		{"-0.5", ""},
+		{"+1", ""},
+		{"1.-5", ""},
+		{"1.2.3", ""},
+		{"0x10", ""},
+		{"ten", ""},
+		{"", ""},
+		{".", ""},
+	}
+	for _, tt := range tests {
+		got, err := ParseEther(tt.amount)
+		switch {
+		case tt.want == "" && err == nil:
+			t.Errorf("ParseEther(%q) = %v, want an error", tt.amount, got)
+		case tt.want != "" && err != nil:
+			t.Errorf("ParseEther(%q): %v", tt.amount, err)
+		case tt.want != "" && got.String() != tt.want:
+			t.Errorf("ParseEther(%q) = %v, want %s", tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestEther(t *testing.T) {
+	tests := []struct {
+		wei  string
+		want string
+	}{
+		{"0", "0"},
+		{"1", "0.000000000000000001"},
+		{"10", "0.00000000000000001"},
+		{"500000000000000000", "0.5"},
+		{"1000000000000000000", "1"},
+		{"1000000000000000001", "1.000000000000000001"},
+		{"2250000000000000000", "2.25"},
+		{"-1500000000000000000", "-1.5"},
+		{"-1", "-0.000000000000000001"},
+	}
+	for _, tt := range tests {
+		wei, _ := new(big.Int).SetString(tt.wei, 10)
+		if got := Ether(wei); got != tt.want {
+			t.Errorf("Ether(%s) = %q, want %q", tt.wei, got, tt.want)
+		}
+	}
+	if got := Ether(nil); got != "0" {
+		t.Errorf("Ether(nil) = %q, want \"0\"", got)
+	}
+}
+
+// TestEtherRoundTrip checks that the amounts Ether formats parse back to the
+// same wei, so that the output of one command can be given to another.
+func TestEtherRoundTrip(t *testing.T) {
+	for _, s := range []string{"0", "1", "9", "10", "99", "100000000000000000", "123456789012345678", "999999999999999999", "1000000000000000000", "31415926535897932384626"} {
+		wei, _ := new(big.Int).SetString(s, 10)
+		got, err := ParseEther(Ether(wei))
+		if err != nil {
+			t.Errorf("ParseEther(Ether(%s)): %v", s, err)
+			continue
+		}
+		if got.Cmp(wei) != 0 {
+			t.Errorf("ParseEther(Ether(%s)) = %v via %q", s, got, Ether(wei))
+		}
+	}
+}
@@ -0,0 +1,233 @@
+// Package simulated runs the four contracts on an in-process chain, go-ethereum's
+// SimulatedBackend, so that the cli can be demoed and tested without a node.
+package simulated
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"time"
+	BKC "win/Code/BKC"
+	Output "win/Code/Output"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ChainID is the chain ID of the simulated backend.
+const ChainID = 1337
+
+// gasLimit is the block gas limit, high enough for updateValidatorSet over a large pool.
+const gasLimit = 30000000
+
+// Delegation is a delegation made when the chain is seeded. Validator is the
+// index of the validator in Config.Validators.
+type Delegation struct {
+	Validator int    `mapstructure:"validator"`
+	Amount    string `mapstructure:"amount"`
+}
+
+// Config describes the seeded accounts. Amounts are in ether. Each delegation
+// is made by its own delegator account.
+type Config struct {
+	Balance    string       `mapstructure:"balance"`
+	Validators []string     `mapstructure:"validators"`
+	Delegators []Delegation `mapstructure:"delegators"`
+}
+
+// DefaultConfig seeds three validators, of which the two with the most power
+// make the active set, and a delegator for each of the first two.
+var DefaultConfig = Config{
+	Balance:    "1000",
+	Validators: []string{"50", "30", "20"},
+	Delegators: []Delegation{{Validator: 0, Amount: "5"}, {Validator: 1, Amount: "5"}},
+}
+
+// Account is a funded account of the simulated chain.
+type Account struct {
+	Key     *ecdsa.PrivateKey
+	Address common.Address
+}
+
+// Chain is a simulated chain with the four contracts deployed and initialised.
+type Chain struct {
+	*Backend
+	Addresses  BKC.Addresses
+	Deployer   Account
+	Validators []Account
+	Delegators []Account
+}
+
+// Backend is the simulated backend mining a block for each transaction, so that
+// transactions are mined by the time SendTransaction returns.
+type Backend struct {
+	*backends.SimulatedBackend
+}
+
+func (b *Backend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	// the simulated backend panics on a nonce other than the next one, which a
+	// node refuses instead
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return err
+	}
+	nonce, err := b.PendingNonceAt(ctx, from)
+	if err != nil {
+		return err
+	}
+	if tx.Nonce() != nonce {
+		return fmt.Errorf("invalid transaction nonce: got %d, want %d", tx.Nonce(), nonce)
+	}
+	if err := b.SimulatedBackend.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	b.Commit()
+	return nil
+}
+
+// TransactionReceipt reports a transaction that isn't mined as ethclient.Client
+// does, with ethereum.NotFound, where the simulated backend returns no receipt
+// and no error.
+func (b *Backend) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	receipt, err := b.SimulatedBackend.TransactionReceipt(ctx, hash)
+	if err == nil && receipt == nil {
+		return nil, ethereum.NotFound
+	}
+	return receipt, err
+}
+
+// ChainID is what ethclient.Client reports, which the simulated backend lacks.
+func (b *Backend) ChainID(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(ChainID), nil
+}
+
+// NewAccount returns the n-th account of the simulated chain. Keys are derived
+// from n so that the addresses are the same on every run.
+func NewAccount(n int) Account {
+	key, err := crypto.ToECDSA(crypto.Keccak256([]byte(fmt.Sprintf("bkc simulated account %d", n))))
+	if err != nil {
+		panic(err)
+	}
+	return Account{Key: key, Address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+// Accounts lists every funded account, the deployer first.
+func (c *Chain) Accounts() []Account {
+	accounts := []Account{c.Deployer}
+	accounts = append(accounts, c.Validators...)
+	return append(accounts, c.Delegators...)
+}
+
+// TransactOpts signs with the account.
+func (a Account) TransactOpts(ctx context.Context) *bind.TransactOpts {
+	opts, err := bind.NewKeyedTransactorWithChainID(a.Key, big.NewInt(ChainID))
+	if err != nil {
+		panic(err)
+	}
+	opts.Context = ctx
+	return opts
+}
+
+// clockLead is how far behind the wall clock the simulated chain starts.
+const clockLead = time.Hour
+
+// New starts a simulated chain whose clock is set to an hour before now, deploys the four
+// contracts, calls init on each of them in dependency order and seeds the
+// accounts of cfg. The validators register and are delegated to while they are
+// unbonded, then updateValidatorSet picks the active set.
+func New(ctx context.Context, cfg Config) (*Chain, error) {
+	balance, err := Output.ParseEther(cfg.Balance)
+	if err != nil {
+		return nil, fmt.Errorf("balance: %v", err)
+	}
+	stakes := make([]*big.Int, len(cfg.Validators))
+	for i, stake := range cfg.Validators {
+		if stakes[i], err = Output.ParseEther(stake); err != nil {
+			return nil, fmt.Errorf("validator %d: %v", i, err)
+		}
+	}
+	delegations := make([]*big.Int, len(cfg.Delegators))
+	for i, d := range cfg.Delegators {
+		if d.Validator < 0 || d.Validator >= len(cfg.Validators) {
+			return nil, fmt.Errorf("delegator %d delegates to validator %d, there are %d validators", i, d.Validator, len(cfg.Validators))
+		}
+		if delegations[i], err = Output.ParseEther(d.Amount); err != nil {
+			return nil, fmt.Errorf("delegator %d: %v", i, err)
+		}
+	}
+
+	c := &Chain{Deployer: NewAccount(0)}
+	for i := range cfg.Validators {
+		c.Validators = append(c.Validators, NewAccount(1+i))
+	}
+	for i := range cfg.Delegators {
+		c.Delegators = append(c.Delegators, NewAccount(1+len(cfg.Validators)+i))
+	}
+	alloc := core.GenesisAlloc{}
+	for _, a := range c.Accounts() {
+		alloc[a.Address] = core.GenesisAccount{Balance: balance}
+	}
+	c.Backend = &Backend{backends.NewSimulatedBackend(alloc, gasLimit)}
+
+	// The genesis block is at 1970 and every block is 10 seconds after its
+	// parent. Blocks more than 30 seconds ahead of the wall clock are held back
+	// by the blockchain, so the clock is moved to an hour before now, leaving
+	// room for a few hundred blocks.
+	genesis, err := c.HeaderByNumber(ctx, big.NewInt(0))
+	if err != nil {
+		return nil, err
+	}
+	if err := c.AdjustTime(time.Since(time.Unix(int64(genesis.Time), 0)) - clockLead); err != nil {
+		return nil, err
+	}
+	c.Commit()
+
+	if c.Addresses, err = BKC.Deploy(ctx, c.Backend, c.Deployer.TransactOpts(ctx), BKC.Addresses{}, nil); err != nil {
+		return nil, err
+	}
+	client, err := BKC.NewClient(c.Backend, c.Addresses)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, v := range c.Validators {
+		opts := v.TransactOpts(ctx)
+		opts.Value = stakes[i]
+		if err := c.mined(client.ValidatorPool.RegisterValidator(opts)); err != nil {
+			return nil, fmt.Errorf("can't register validator %d: %w", i, err)
+		}
+	}
+	for i, d := range c.Delegators {
+		opts := d.TransactOpts(ctx)
+		opts.Value = delegations[i]
+		if err := c.mined(client.StakePool.Delegate(opts, c.Validators[cfg.Delegators[i].Validator].Address)); err != nil {
+			return nil, fmt.Errorf("can't delegate from delegator %d: %w", i, err)
+		}
+	}
+	if err := c.mined(client.ValidatorSet.UpdateValidatorSet(c.Deployer.TransactOpts(ctx))); err != nil {
+		return nil, fmt.Errorf("can't update the validator set: %w", err)
+	}
+	return c, nil
+}
+
+// mined checks the receipt of a transaction sent through the Backend, which is
+// mined already.
+func (c *Chain) mined(tx *types.Transaction, err error) error {
+	if err != nil {
+		return err
+	}
+	receipt, err := c.TransactionReceipt(context.Background(), tx.Hash())
+	if err != nil {
+		return err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return fmt.Errorf("transaction %s failed", tx.Hash().Hex())
+	}
+	return nil
+}
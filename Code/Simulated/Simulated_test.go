@@ -0,0 +1,120 @@
+package simulated
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	BKC "win/Code/BKC"
+	IValidator "win/Code/IValidator"
+	Output "win/Code/Output"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func newChain(t *testing.T) (*Chain, *BKC.Client) {
+	chain, err := New(context.Background(), DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { chain.Close() })
+	c, err := BKC.NewClient(chain.Backend, chain.Addresses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chain, c
+}
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+	chain, c := newChain(t)
+	if len(chain.Validators) != 3 || len(chain.Delegators) != 2 {
+		t.Fatalf("%d validators and %d delegators, want 3 and 2", len(chain.Validators), len(chain.Delegators))
+	}
+
+	// the first two validators have the most power and make the active set
+	active, err := c.ActiveValidators(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inSet := map[common.Address]bool{}
+	for _, v := range active {
+		inSet[v.ConsensusAddress] = true
+	}
+	if len(active) != 2 || !inSet[chain.Validators[0].Address] || !inSet[chain.Validators[1].Address] {
+		t.Errorf("active set %v, want validators 0 and 1", active)
+	}
+	for i, want := range []uint8{BKC.Bonded, BKC.Bonded, BKC.Unbonded} {
+		v, _, err := c.PoolValidatorByAddress(ctx, chain.Validators[i].Address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.BondStatus != want {
+			t.Errorf("validator %d is %s, want %s", i, IValidator.BondStatusName(v.BondStatus), IValidator.BondStatusName(want))
+		}
+	}
+
+	for i, d := range DefaultConfig.Delegators {
+		got, err := c.Delegation(ctx, chain.Validators[d.Validator].Address, chain.Delegators[i].Address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if Output.Ether(got) != d.Amount {
+			t.Errorf("delegator %d delegates %s ether to validator %d, want %s", i, Output.Ether(got), d.Validator, d.Amount)
+		}
+	}
+}
+
+func TestBackend(t *testing.T) {
+	ctx := context.Background()
+	chain, _ := newChain(t)
+
+	from := chain.Deployer
+	nonce, err := chain.PendingNonceAt(ctx, from.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := types.NewTransaction(nonce+1, chain.Validators[0].Address, big.NewInt(1), 21000, big.NewInt(1e9), nil)
+	signed, err := from.TransactOpts(ctx).Signer(from.Address, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.SendTransaction(ctx, signed); err == nil || !strings.Contains(err.Error(), "nonce") {
+		t.Errorf("SendTransaction with a nonce gap = %v, want a nonce error", err)
+	}
+
+	if receipt, err := chain.TransactionReceipt(ctx, signed.Hash()); !errors.Is(err, ethereum.NotFound) {
+		t.Errorf("TransactionReceipt of a transaction never sent = %v, %v, want %v", receipt, err, ethereum.NotFound)
+	}
+}
+
+// TestFailedError checks that the revert reason of a mined transaction is found
+// although the simulated backend can't replay it on the block before.
+func TestFailedError(t *testing.T) {
+	ctx := context.Background()
+	chain, c := newChain(t)
+
+	// validator 2 is unbonded, the only state a top up is allowed in
+	validator := chain.Validators[2]
+	opts := validator.TransactOpts(ctx)
+	// a fixed gas limit skips the estimation, which would refuse the top up
+	opts.GasLimit = 200000
+	tx, err := c.ValidatorPool.ValidatorTopUp(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt, err := chain.TransactionReceipt(ctx, tx.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.Status != types.ReceiptStatusFailed {
+		t.Fatal("the top up of 0 ether didn't fail")
+	}
+	err = c.FailedError(ctx, tx, validator.Address, receipt)
+	if !errors.Is(err, BKC.ErrZeroTopUp) || !strings.Contains(err.Error(), BKC.ErrZeroTopUp.Reason) {
+		t.Errorf("FailedError = %v, want the reason %q", err, BKC.ErrZeroTopUp.Reason)
+	}
+}
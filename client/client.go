@@ -0,0 +1,17 @@
+package client
+
+import (
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// DefaultURL is the endpoint of a local Ganache instance.
+const DefaultURL = "http://localhost:7545"
+
+func Client(url string) (*ethclient.Client, error) {
+	if url == "" {
+		url = DefaultURL
+	}
+	client, err := ethclient.Dial(url)
+
+	return client, err
+}
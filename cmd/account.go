@@ -0,0 +1,181 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	BKC "win/Code/BKC"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// accountCmd represents the account command
+var accountCmd = &cobra.Command{
+	Use:   "account",
+	Short: "manage the keystore accounts used to sign transactions",
+	Long: `Accounts are kept encrypted in the keystore directory (--keystore, by default
+keystore under --datadir). The passphrase is read from --password-file, from
+CLI_PASSWORD or, on a terminal, from a prompt. Write commands sign with the
+account given by --from.
+
+With --signer the keys are held by an external signer compatible with Clef,
+which is asked to approve every transaction, and the cli never sees them.`,
+}
+
+var accountNewCmd = &cobra.Command{
+	Use:   "new",
+	Short: "create a new account",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ks, err := openKeystore()
+		handleError(err)
+		pass, err := passphrase("Passphrase of the new account: ", true)
+		handleError(err)
+		a, err := ks.NewAccount(pass)
+		handleError(err)
+		render(newAccountView(a))
+	},
+}
+
+var accountImportCmd = &cobra.Command{
+	Use:   "import <hex-key|json>",
+	Short: "import a private key, or a JSON key file, into the keystore",
+	Long: `Imports a hex encoded private key, or a JSON key file given by its path or its
+content. A JSON key file is decrypted with the passphrase, which then encrypts
+the imported account as well.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ks, err := openKeystore()
+		handleError(err)
+
+		keyJSON, key, err := parseKey(args[0])
+		handleError(err)
+		var a accounts.Account
+		if key != nil {
+			pass, err := passphrase("Passphrase of the imported account: ", true)
+			handleError(err)
+			a, err = ks.ImportECDSA(key, pass)
+			handleError(keystoreError(err))
+		} else {
+			pass, err := passphrase("Passphrase of the key file: ", false)
+			handleError(err)
+			a, err = ks.Import(keyJSON, pass, pass)
+			handleError(keystoreError(err))
+		}
+		render(newAccountView(a))
+	},
+}
+
+var accountListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the accounts of the keystore, or of the external signer of --signer",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var list []accounts.Account
+		if viper.GetString("signer") != "" {
+			signer, err := dialSigner()
+			handleError(err)
+			list = signer.Accounts()
+		} else {
+			ks, err := openKeystore()
+			handleError(err)
+			list = ks.Accounts()
+		}
+		views := []AccountView{}
+		for _, a := range list {
+			views = append(views, newAccountView(a))
+		}
+		render(views)
+	},
+}
+
+var accountExportCmd = &cobra.Command{
+	Use:   "export <address>",
+	Short: "write the JSON key file of an account to stdout",
+	Args:  addressArgs("account"),
+	Run: func(cmd *cobra.Command, args []string) {
+		ks, err := openKeystore()
+		handleError(err)
+		a, err := ks.Find(accounts.Account{Address: common.HexToAddress(args[0])})
+		handleError(keystoreError(err))
+		pass, err := passphrase("Passphrase of "+a.Address.Hex()+": ", false)
+		handleError(err)
+		keyJSON, err := ks.Export(a, pass, pass)
+		handleError(keystoreError(err))
+		fmt.Println(string(keyJSON))
+	},
+}
+
+// AccountView is the output form of a keystore account.
+type AccountView struct {
+	Address string `json:"address" yaml:"address"`
+	Path    string `json:"path" yaml:"path"`
+}
+
+func newAccountView(a accounts.Account) AccountView {
+	return AccountView{Address: a.Address.Hex(), Path: a.URL.Path}
+}
+
+func init() {
+	rootCmd.AddCommand(accountCmd)
+	accountCmd.AddCommand(accountNewCmd)
+	accountCmd.AddCommand(accountImportCmd)
+	accountCmd.AddCommand(accountListCmd)
+	accountCmd.AddCommand(accountExportCmd)
+}
+
+// parseKey reads the argument of account import: a hex private key, a JSON key
+// file or the path of one.
+func parseKey(arg string) ([]byte, *ecdsa.PrivateKey, error) {
+	arg = strings.TrimSpace(arg)
+	if strings.HasPrefix(arg, "{") {
+		return []byte(arg), nil, nil
+	}
+	if key, err := crypto.HexToECDSA(strings.TrimPrefix(arg, "0x")); err == nil {
+		return nil, key, nil
+	}
+	keyJSON, err := ioutil.ReadFile(arg)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, BKC.Errorf(BKC.KindUsage, "the argument is neither a hex private key nor a JSON key file")
+		}
+		return nil, nil, err
+	}
+	return keyJSON, nil, nil
+}
+
+// keystoreError gives a kind to the errors of the keystore.
+func keystoreError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, keystore.ErrDecrypt), errors.Is(err, keystore.ErrAccountAlreadyExists):
+		return usageError(err)
+	case errors.Is(err, keystore.ErrNoMatch), errors.Is(err, accounts.ErrUnknownAccount):
+		return &BKC.Error{Kind: BKC.KindNotFound, Err: err}
+	}
+	return err
+}
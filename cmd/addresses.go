@@ -1,6 +1,65 @@
 package cmd
 
-var BKCValidatorSetAddress string = "0x5c98aF17D468142d0367fBb8826f8102A161fdC9"
-var StakePoolAddress string = "0xE2258E17c06dD825b0F39d19a7D07Cd732f71e11"
-var SystemRewardAddress string = "0x1ff5F84323EEa597F03AF05a45d8f3579dE5723D"
-var ValidatorPoolAddress string = "0x3232c1966A897b3e1796bf11bAab3913a6763B49"
+import (
+	BKC "win/Code/BKC"
+	ETHclient "win/client"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Network is a named deployment of the four contracts. Profiles are read from
+// the networks section of the config file, e.g.
+//
+//	network: devnet
+//	networks:
+//	  devnet:
+//	    rpc: http://10.0.0.5:8545
+//	    chainid: 1337
+//	    validatorset: 0x5c98aF17D468142d0367fBb8826f8102A161fdC9
+//	    stakepool: 0xE2258E17c06dD825b0F39d19a7D07Cd732f71e11
+//	    systemreward: 0x1ff5F84323EEa597F03AF05a45d8f3579dE5723D
+//	    vldpool: 0x3232c1966A897b3e1796bf11bAab3913a6763B49
+type Network struct {
+	RPC           string `mapstructure:"rpc" yaml:"rpc"`
+	ChainID       uint64 `mapstructure:"chainid" yaml:"chainid,omitempty"`
+	ValidatorSet  string `mapstructure:"validatorset" yaml:"validatorset"`
+	StakePool     string `mapstructure:"stakepool" yaml:"stakepool"`
+	SystemReward  string `mapstructure:"systemreward" yaml:"systemreward"`
+	ValidatorPool string `mapstructure:"vldpool" yaml:"vldpool"`
+}
+
+// defaultNetworkName is the profile used when neither --network nor the config selects one.
+const defaultNetworkName = "local"
+
+// localNetwork is the local Ganache deployment, used unless the config file defines its own "local" profile.
+var localNetwork = Network{
+	RPC:           ETHclient.DefaultURL,
+	ValidatorSet:  "0x5c98aF17D468142d0367fBb8826f8102A161fdC9",
+	StakePool:     "0xE2258E17c06dD825b0F39d19a7D07Cd732f71e11",
+	SystemReward:  "0x1ff5F84323EEa597F03AF05a45d8f3579dE5723D",
+	ValidatorPool: "0x3232c1966A897b3e1796bf11bAab3913a6763B49",
+}
+
+// Addresses parses the contract addresses of the profile. Missing ones are left as the zero address.
+func (n Network) Addresses() (BKC.Addresses, error) {
+	var addrs BKC.Addresses
+	for _, field := range []struct {
+		name  string
+		value string
+		addr  *common.Address
+	}{
+		{"validatorset", n.ValidatorSet, &addrs.ValidatorSet},
+		{"stakepool", n.StakePool, &addrs.StakePool},
+		{"systemreward", n.SystemReward, &addrs.SystemReward},
+		{"vldpool", n.ValidatorPool, &addrs.ValidatorPool},
+	} {
+		if field.value == "" {
+			continue
+		}
+		if !common.IsHexAddress(field.value) {
+			return addrs, BKC.Errorf(BKC.KindUsage, "invalid %s address %q", field.name, field.value)
+		}
+		*field.addr = common.HexToAddress(field.value)
+	}
+	return addrs, nil
+}
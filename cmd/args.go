@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+	BKC "win/Code/BKC"
+	Output "win/Code/Output"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// addressArgs accepts exactly the named positional arguments, each of which must be an address.
+func addressArgs(names ...string) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(args) != len(names) {
+			return BKC.Errorf(BKC.KindUsage, "expected %d argument(s) <%s>, got %d", len(names), strings.Join(names, "> <"), len(args))
+		}
+		for i, arg := range args {
+			if !common.IsHexAddress(arg) {
+				return BKC.Errorf(BKC.KindUsage, "invalid %s address %q", names[i], arg)
+			}
+		}
+		return nil
+	}
+}
+
+// indexOrAddressArg accepts a single argument that is either an index or an address.
+func indexOrAddressArg(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return BKC.Errorf(BKC.KindUsage, "expected 1 argument <index|address>, got %d", len(args))
+	}
+	if _, _, err := parseIndexOrAddress(args[0]); err != nil {
+		return err
+	}
+	return nil
+}
+
+// parseIndexOrAddress returns the index, or -1 and the address.
+func parseIndexOrAddress(arg string) (int, common.Address, error) {
+	if common.IsHexAddress(arg) {
+		return -1, common.HexToAddress(arg), nil
+	}
+	idx, err := strconv.Atoi(arg)
+	if err != nil || idx < 0 {
+		return 0, common.Address{}, BKC.Errorf(BKC.KindUsage, "invalid argument %q, expected an index (starting at 0) or an address", arg)
+	}
+	return idx, common.Address{}, nil
+}
+
+// legacyFlag returns the name of the one deprecated query flag given to cmd, or ""
+// when there is none. The flags used to be checked in a fixed order, silently
+// ignoring all but the first, so giving more than one is now an error. Modifiers
+// names deprecated flags that only alter another one.
+func legacyFlag(cmd *cobra.Command, modifiers ...string) (string, error) {
+	isModifier := map[string]bool{}
+	for _, name := range modifiers {
+		isModifier[name] = true
+	}
+	given := []string{}
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if f.Deprecated != "" && !isModifier[f.Name] {
+			given = append(given, "--"+f.Name)
+		}
+	})
+	if len(given) > 1 {
+		return "", BKC.Errorf(BKC.KindUsage, "only one of %s can be given, use the subcommands instead", strings.Join(given, ", "))
+	}
+	if len(given) == 0 {
+		return "", nil
+	}
+	return strings.TrimPrefix(given[0], "--"), nil
+}
+
+// addressPair reads a deprecated flag holding a validator and a delegator address separated by a comma.
+func addressPair(cmd *cobra.Command, name string) ([]string, error) {
+	addrs, err := cmd.Flags().GetStringSlice(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) != 2 {
+		return nil, BKC.Errorf(BKC.KindUsage, "--%s expects the validator address then the delegator address separated by a comma", name)
+	}
+	for _, addr := range addrs {
+		if !common.IsHexAddress(addr) {
+			return nil, BKC.Errorf(BKC.KindUsage, "invalid address %q given to --%s", addr, name)
+		}
+	}
+	return addrs, nil
+}
+
+// addressFlag reads a deprecated flag holding an address, refused when it isn't
+// one rather than read as whatever common.HexToAddress makes of it.
+func addressFlag(cmd *cobra.Command, name string) (string, error) {
+	addr, err := cmd.Flags().GetString(name)
+	if err != nil {
+		return "", err
+	}
+	if !common.IsHexAddress(addr) {
+		return "", BKC.Errorf(BKC.KindUsage, "invalid address %q given to --%s", addr, name)
+	}
+	return addr, nil
+}
+
+// etherFlag reads a required amount in ether given to the flag, e.g. --amount 10.5, in wei.
+func etherFlag(cmd *cobra.Command, name string) (*big.Int, error) {
+	value, err := cmd.Flags().GetString(name)
+	if err != nil {
+		return nil, err
+	}
+	if value == "" {
+		return nil, BKC.Errorf(BKC.KindUsage, "--%s is required", name)
+	}
+	wei, err := Output.ParseEther(value)
+	if err != nil {
+		return nil, BKC.Errorf(BKC.KindUsage, "--%s: %v", name, err)
+	}
+	return wei, nil
+}
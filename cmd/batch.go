@@ -0,0 +1,587 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	BKC "win/Code/BKC"
+	Output "win/Code/Output"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// batchCmd represents the batch command
+var batchCmd = &cobra.Command{
+	Use:   "batch",
+	Short: "send a series of transactions from a plan file",
+}
+
+var batchRunCmd = &cobra.Command{
+	Use:   "run <plan.yaml>",
+	Short: "check a plan of operations against the chain, then send them in order",
+	Long: `Runs the steps of a plan file, all signed by --from. A plan looks like:
+
+  on_failure: stop        # or continue
+  steps:
+    - op: delegate
+      validator: 0x089aa61131D80b7AF69083d2Ea7651e9667fC536
+      amount: 10
+    - op: add-reward
+      validator: active     # one step per validator of the active set
+      amount: 1
+    - op: topup
+      amount: 100
+
+The operations are delegate, undelegate and add-reward, which take a validator
+and an amount in ether, topup and fund, which take an amount, and claim, which
+takes neither. They act as stakepool delegate, stakepool undelegate,
+systemreward add-reward, vldpool topup, systemreward fund and stakepool claim.
+
+Every step is checked against the current state of the chain first, the
+undelegations of a validator together, net of what the plan delegates to it
+before, and the ether sent along against the balance of --from. Nothing is
+sent unless all of them pass. The plan is then shown on stderr and the steps
+are sent one at a time, each waited for with --confirmations, taking their
+nonces from the nonce manager.
+
+When a step fails the run stops, leaving the rest skipped, or goes on with the
+next one with on_failure: continue or --on-failure continue. The outcome of
+every step is written to --report after each of them, and shown at the end.
+A step whose transaction was sent but not seen mined, e.g. when the run is
+interrupted, is sent, not confirmed, with its hash for tx show. The exit code
+is the one of the first failure.
+
+With --dry-run every step is simulated against the current state and the
+outcomes are shown without writing the report. A step that relies on an
+earlier one, e.g. an undelegation of what the plan delegates, fails there but
+not when sent.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		if unsignedOut != "" {
+			handleError(BKC.Errorf(BKC.KindUsage, "--unsigned-out can't be used with batch run, run the commands of the plan one at a time"))
+		}
+		plan, err := readPlan(args[0])
+		handleError(err)
+		policy, err := cmd.Flags().GetString("on-failure")
+		handleError(err)
+		if policy == "" {
+			policy = plan.OnFailure
+		}
+		if policy == "" {
+			policy = "stop"
+		}
+		if policy != "stop" && policy != "continue" {
+			handleError(BKC.Errorf(BKC.KindUsage, "the failure policy must be stop or continue, not %q", policy))
+		}
+		report, err := cmd.Flags().GetString("report")
+		handleError(err)
+		if report == "" {
+			report = strings.TrimSuffix(args[0], filepath.Ext(args[0])) + ".report.json"
+		}
+
+		c, err := writeClient()
+		handleError(err)
+		opts, err := transactOpts(ctx, c)
+		handleError(err)
+		steps, err := expandPlan(ctx, c, plan)
+		handleError(err)
+		handleError(checkPlan(ctx, c, opts.From, args[0], steps))
+		showPlan(opts.From, policy, steps)
+
+		result := BatchReport{
+			Plan:      args[0],
+			Network:   currentNetworkName(),
+			Signer:    opts.From.Hex(),
+			OnFailure: policy,
+		}
+		for _, s := range steps {
+			result.Steps = append(result.Steps, s.result())
+		}
+		if dryRun {
+			failure := dryRunPlan(ctx, c, opts, steps, &result)
+			result.count()
+			render(result)
+			exitOnFailure(failure)
+			return
+		}
+
+		failure, err := runPlan(ctx, c, opts, policy, report, steps, &result)
+		handleError(err)
+		fmt.Fprintln(os.Stderr, "Wrote the report to", report)
+
+		render(result)
+		exitOnFailure(failure)
+	},
+}
+
+// dryRunPlan simulates every step against the current state and returns the
+// first failure.
+func dryRunPlan(ctx context.Context, c *BKC.Client, opts *bind.TransactOpts, steps []batchStep, result *BatchReport) error {
+	var failure error
+	for i, s := range steps {
+		stepOpts := *opts
+		simulated, err := simulate(ctx, c, &stepOpts, s.subject(opts.From), s.build(c))
+		result.Steps[i].Status = "would succeed"
+		result.Steps[i].GasUsed = simulated.Gas
+		if err != nil {
+			failure = stepFailed(&result.Steps[i], failure, err)
+			result.Steps[i].Status = "would fail"
+		}
+	}
+	return failure
+}
+
+// runPlan sends the steps in order, following the failure policy, and writes
+// the report after each of them. It returns the first failure of a step, and
+// an error when the report can't be written.
+func runPlan(ctx context.Context, c *BKC.Client, opts *bind.TransactOpts, policy, report string, steps []batchStep, result *BatchReport) (failure, err error) {
+	if err := writeReport(report, result); err != nil {
+		return nil, err
+	}
+	for i, s := range steps {
+		if ctx.Err() != nil || (failure != nil && policy == "stop") {
+			result.Steps[i].Status = "skipped"
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Step %d of %d: %s\n", s.number, len(steps), s.describe())
+		// every step gets its own copy, the builders set the value sent along
+		stepOpts := *opts
+		tx, receipt, err := sendTx(ctx, c, &stepOpts, s.subject(opts.From), s.build(c))
+		if tx != nil {
+			result.Steps[i].Tx = tx.Hash().Hex()
+		}
+		if receipt != nil {
+			result.Steps[i].Block = receipt.BlockNumber.Uint64()
+			result.Steps[i].GasUsed = receipt.GasUsed
+		}
+		result.Steps[i].Status = "success"
+		switch {
+		case err != nil && tx != nil && receipt == nil:
+			// the transaction may still be mined, tx show tells
+			failure = stepFailed(&result.Steps[i], failure, err)
+			result.Steps[i].Status = "sent, not confirmed"
+			fmt.Fprintf(os.Stderr, "Step %d was sent but isn't confirmed: %v\n", s.number, err)
+		case err != nil:
+			failure = stepFailed(&result.Steps[i], failure, err)
+			fmt.Fprintf(os.Stderr, "Step %d failed: %v\n", s.number, err)
+		}
+		if err := writeReport(report, result); err != nil {
+			return failure, err
+		}
+	}
+	return failure, writeReport(report, result)
+}
+
+// Plan is a plan file of batch run.
+type Plan struct {
+	OnFailure string     `yaml:"on_failure"`
+	Steps     []PlanStep `yaml:"steps"`
+}
+
+// PlanStep is an operation of a plan. Validator is an address, or active for
+// every validator of the active set.
+type PlanStep struct {
+	Op        string `yaml:"op"`
+	Validator string `yaml:"validator"`
+	Amount    string `yaml:"amount"`
+}
+
+// BatchReport is the outcome of batch run, written to the report file after
+// every step. The status of a step is pending until it is sent, then success or
+// failed, or skipped when the run stopped before it. It is sent, not confirmed
+// when the wait for its transaction failed, which may still be mined. With
+// --dry-run it is would succeed, with the estimated gas, or would fail.
+type BatchReport struct {
+	Plan        string       `json:"plan" yaml:"plan"`
+	Network     string       `json:"network" yaml:"network"`
+	Signer      string       `json:"signer" yaml:"signer"`
+	OnFailure   string       `json:"on_failure" yaml:"on_failure"`
+	Succeeded   int          `json:"succeeded" yaml:"succeeded"`
+	Failed      int          `json:"failed" yaml:"failed"`
+	Skipped     int          `json:"skipped" yaml:"skipped"`
+	Unconfirmed int          `json:"unconfirmed" yaml:"unconfirmed"`
+	Steps       []StepResult `json:"steps" yaml:"steps"`
+}
+
+// StepResult is the outcome of a step of batch run.
+type StepResult struct {
+	Step        int    `json:"step" yaml:"step"`
+	Op          string `json:"op" yaml:"op"`
+	Validator   string `json:"validator,omitempty" yaml:"validator,omitempty"`
+	AmountEther string `json:"amount_ether,omitempty" yaml:"amount_ether,omitempty"`
+	Status      string `json:"status" yaml:"status"`
+	Tx          string `json:"tx,omitempty" yaml:"tx,omitempty"`
+	Block       uint64 `json:"block,omitempty" yaml:"block,omitempty"`
+	GasUsed     uint64 `json:"gas_used,omitempty" yaml:"gas_used,omitempty"`
+	Error       string `json:"error,omitempty" yaml:"error,omitempty"`
+	Hint        string `json:"hint,omitempty" yaml:"hint,omitempty"`
+}
+
+// stepFailed records err as the outcome of the step and returns the first
+// failure of the run, which is err unless there was one before.
+func stepFailed(r *StepResult, first error, err error) error {
+	err = BKC.Classify(err)
+	r.Status = "failed"
+	r.Error = err.Error()
+	var e *BKC.Error
+	if errors.As(err, &e) {
+		r.Hint = e.Hint
+	}
+	if first == nil {
+		return err
+	}
+	return first
+}
+
+// exitOnFailure exits with the exit code of the first failure of the run, if
+// any. The failures are in the report, which is the only output.
+func exitOnFailure(first error) {
+	if first != nil {
+		os.Exit(exitCode(BKC.KindOf(first)))
+	}
+}
+
+// batchOp is an operation a plan can run. check tells whether the step would go
+// through against the current state, for the signer from.
+type batchOp struct {
+	validator bool
+	amount    bool
+	payable   bool
+	check     func(ctx context.Context, c *BKC.Client, from common.Address, s batchStep) error
+	build     func(c *BKC.Client, s batchStep) txBuilder
+}
+
+// batchOps are the operations of a plan, by name.
+var batchOps = map[string]batchOp{
+	"delegate": {
+		validator: true, amount: true, payable: true,
+		check: func(ctx context.Context, c *BKC.Client, from common.Address, s batchStep) error {
+			return c.CheckDelegate(ctx, s.validator, s.amount)
+		},
+		build: func(c *BKC.Client, s batchStep) txBuilder {
+			return func(opts *bind.TransactOpts) (*types.Transaction, error) {
+				opts.Value = s.amount
+				return c.StakePool.Delegate(opts, s.validator)
+			}
+		},
+	},
+	"undelegate": {
+		validator: true, amount: true,
+		// checked in checkPlan, together with the other undelegations of the validator
+		build: func(c *BKC.Client, s batchStep) txBuilder {
+			return func(opts *bind.TransactOpts) (*types.Transaction, error) {
+				return c.StakePool.Undelegate(opts, s.validator, s.amount)
+			}
+		},
+	},
+	"add-reward": {
+		validator: true, amount: true, payable: true,
+		check: func(ctx context.Context, c *BKC.Client, from common.Address, s batchStep) error {
+			_, err := c.CheckAddReward(ctx, s.validator, s.amount)
+			return err
+		},
+		build: func(c *BKC.Client, s batchStep) txBuilder {
+			return func(opts *bind.TransactOpts) (*types.Transaction, error) {
+				opts.Value = s.amount
+				return c.SystemReward.AddReward(opts, s.validator)
+			}
+		},
+	},
+	"topup": {
+		amount: true, payable: true,
+		check: func(ctx context.Context, c *BKC.Client, from common.Address, s batchStep) error {
+			return c.CheckTopUp(ctx, from, s.amount)
+		},
+		build: func(c *BKC.Client, s batchStep) txBuilder {
+			return func(opts *bind.TransactOpts) (*types.Transaction, error) {
+				opts.Value = s.amount
+				return c.ValidatorPool.ValidatorTopUp(opts)
+			}
+		},
+	},
+	"fund": {
+		amount: true, payable: true,
+		check: func(ctx context.Context, c *BKC.Client, from common.Address, s batchStep) error {
+			if s.amount.Sign() <= 0 {
+				return BKC.Errorf(BKC.KindUsage, "the amount must be greater than 0")
+			}
+			return nil
+		},
+		build: func(c *BKC.Client, s batchStep) txBuilder {
+			return func(opts *bind.TransactOpts) (*types.Transaction, error) {
+				opts.Value = s.amount
+				return c.SystemReward.Fund(opts)
+			}
+		},
+	},
+	"claim": {
+		check: func(ctx context.Context, c *BKC.Client, from common.Address, s batchStep) error {
+			mature, _, _, err := c.MatureUndelegations(ctx, from)
+			if err == nil && len(mature) == 0 {
+				err = BKC.Errorf(BKC.KindNotFound, "no undelegation of %s has matured yet", from.Hex())
+			}
+			return err
+		},
+		build: func(c *BKC.Client, s batchStep) txBuilder {
+			return c.StakePool.RemoveUnbondingUserFromUnbondingQueue
+		},
+	},
+}
+
+// batchStep is a step of a plan with its arguments parsed, numbered from 1
+// after the steps for the active set are expanded.
+type batchStep struct {
+	number    int
+	op        string
+	validator common.Address
+	amount    *big.Int
+}
+
+// describe is the step in words, e.g. "delegate 10 ether to 0x08…".
+func (s batchStep) describe() string {
+	switch s.op {
+	case "delegate", "add-reward":
+		return fmt.Sprintf("%s %s ether to %s", s.op, Output.Ether(s.amount), s.validator.Hex())
+	case "undelegate":
+		return fmt.Sprintf("undelegate %s ether from %s", Output.Ether(s.amount), s.validator.Hex())
+	case "claim":
+		return "claim the matured undelegations"
+	}
+	return fmt.Sprintf("%s %s ether", s.op, Output.Ether(s.amount))
+}
+
+// subject is the validator whose queues explain a revert of the step.
+func (s batchStep) subject(from common.Address) common.Address {
+	if batchOps[s.op].validator {
+		return s.validator
+	}
+	return from
+}
+
+// build is the transaction of the step.
+func (s batchStep) build(c *BKC.Client) txBuilder {
+	return batchOps[s.op].build(c, s)
+}
+
+// result is the pending outcome of the step.
+func (s batchStep) result() StepResult {
+	r := StepResult{Step: s.number, Op: s.op, Status: "pending"}
+	if batchOps[s.op].validator {
+		r.Validator = s.validator.Hex()
+	}
+	if s.amount != nil {
+		r.AmountEther = Output.Ether(s.amount)
+	}
+	return r
+}
+
+// readPlan reads and checks the plan file. Unknown keys are refused, so that a
+// misspelt one isn't silently ignored.
+func readPlan(path string) (Plan, error) {
+	var plan Plan
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return plan, usageError(err)
+	}
+	if err := yaml.UnmarshalStrict(data, &plan); err != nil {
+		return plan, BKC.Errorf(BKC.KindUsage, "can't read the plan %s: %v", path, err)
+	}
+	if len(plan.Steps) == 0 {
+		return plan, BKC.Errorf(BKC.KindUsage, "the plan %s has no steps", path)
+	}
+	for i, s := range plan.Steps {
+		op, ok := batchOps[s.Op]
+		switch {
+		case !ok:
+			return plan, BKC.Errorf(BKC.KindUsage, "step %d of the plan: unknown op %q, expected one of delegate, undelegate, add-reward, topup, fund and claim", i+1, s.Op)
+		case op.validator && s.Validator == "":
+			return plan, BKC.Errorf(BKC.KindUsage, "step %d of the plan: %s needs a validator", i+1, s.Op)
+		case op.validator && s.Validator != "active" && !common.IsHexAddress(s.Validator):
+			return plan, BKC.Errorf(BKC.KindUsage, "step %d of the plan: invalid validator %q", i+1, s.Validator)
+		case !op.validator && s.Validator != "":
+			return plan, BKC.Errorf(BKC.KindUsage, "step %d of the plan: %s takes no validator, it acts on --from", i+1, s.Op)
+		case op.amount && s.Amount == "":
+			return plan, BKC.Errorf(BKC.KindUsage, "step %d of the plan: %s needs an amount", i+1, s.Op)
+		case !op.amount && s.Amount != "":
+			return plan, BKC.Errorf(BKC.KindUsage, "step %d of the plan: %s takes no amount", i+1, s.Op)
+		}
+		if s.Amount != "" {
+			if _, err := Output.ParseEther(s.Amount); err != nil {
+				return plan, BKC.Errorf(BKC.KindUsage, "step %d of the plan: %v", i+1, err)
+			}
+		}
+	}
+	return plan, nil
+}
+
+// expandPlan parses the steps of the plan, with a step per validator of the
+// active set for the steps whose validator is active.
+func expandPlan(ctx context.Context, c *BKC.Client, plan Plan) ([]batchStep, error) {
+	var active []BKC.Validator
+	steps := []batchStep{}
+	for _, s := range plan.Steps {
+		step := batchStep{op: s.Op}
+		if s.Amount != "" {
+			step.amount, _ = Output.ParseEther(s.Amount)
+		}
+		if s.Validator != "active" {
+			step.validator = common.HexToAddress(s.Validator)
+			steps = append(steps, step)
+			continue
+		}
+		if active == nil {
+			var err error
+			if active, err = c.ActiveValidators(ctx); err != nil {
+				return nil, err
+			}
+			if len(active) == 0 {
+				return nil, BKC.Errorf(BKC.KindNotFound, "the plan has steps for the active set, which is empty")
+			}
+		}
+		for _, v := range active {
+			step.validator = v.ConsensusAddress
+			steps = append(steps, step)
+		}
+	}
+	for i := range steps {
+		steps[i].number = i + 1
+	}
+	return steps, nil
+}
+
+// checkPlan checks every step against the current state and reports on stderr
+// those that wouldn't go through. The undelegations of a validator are checked
+// as their running total, less what the steps before delegate to it, and the
+// ether sent along against the balance of from.
+func checkPlan(ctx context.Context, c *BKC.Client, from common.Address, path string, steps []batchStep) error {
+	var first error
+	failed := 0
+	problem := func(s batchStep, err error) {
+		fmt.Fprintf(os.Stderr, "Step %d, %s: %v\n", s.number, s.describe(), err)
+		if first == nil {
+			first = err
+		}
+		failed++
+	}
+
+	delegated, undelegated := map[common.Address]*big.Int{}, map[common.Address]*big.Int{}
+	sent := new(big.Int)
+	for _, s := range steps {
+		op := batchOps[s.op]
+		var err error
+		switch {
+		case s.op == "undelegate" && s.amount.Sign() > 0:
+			// what the plan has undelegated so far beyond what it delegated
+			net := new(big.Int).Sub(total(undelegated, s.validator), total(delegated, s.validator))
+			net.Add(net, s.amount)
+			total(undelegated, s.validator).Add(total(undelegated, s.validator), s.amount)
+			if net.Sign() > 0 {
+				err = c.CheckUndelegate(ctx, s.validator, from, net)
+			} else {
+				_, _, err = c.PoolValidatorByAddress(ctx, s.validator)
+			}
+		case s.op == "undelegate":
+			err = c.CheckUndelegate(ctx, s.validator, from, s.amount)
+		default:
+			err = op.check(ctx, c, from, s)
+		}
+		if s.op == "delegate" && err == nil {
+			total(delegated, s.validator).Add(total(delegated, s.validator), s.amount)
+		}
+		if kind := BKC.KindOf(err); kind == BKC.KindConnection || kind == BKC.KindNotInitialised {
+			return err
+		}
+		if err != nil {
+			problem(s, c.Explain(ctx, err, s.subject(from)))
+		}
+		if op.payable {
+			sent.Add(sent, s.amount)
+		}
+	}
+
+	balance, err := c.Backend().BalanceAt(ctx, from, nil)
+	if err != nil {
+		return err
+	}
+	if balance.Cmp(sent) < 0 {
+		err := BKC.Errorf(BKC.KindUsage, "the plan sends %s ether along but %s has %s ether", Output.Ether(sent), from.Hex(), Output.Ether(balance))
+		fmt.Fprintln(os.Stderr, "Plan:", err)
+		if first == nil {
+			first = err
+		}
+	}
+	if first != nil {
+		if failed == 0 {
+			return first
+		}
+		return BKC.Errorf(BKC.KindOf(first), "%d of the %d steps of %s wouldn't go through, nothing was sent", failed, len(steps), path)
+	}
+	return nil
+}
+
+// total is the running total of the validator in amounts, added on first use.
+func total(amounts map[common.Address]*big.Int, validator common.Address) *big.Int {
+	if amounts[validator] == nil {
+		amounts[validator] = new(big.Int)
+	}
+	return amounts[validator]
+}
+
+// showPlan writes the steps about to be sent to stderr.
+func showPlan(from common.Address, policy string, steps []batchStep) {
+	total := new(big.Int)
+	for _, s := range steps {
+		if batchOps[s.op].payable {
+			total.Add(total, s.amount)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Plan of %d steps on %s, signed by %s, sending %s ether along, on failure %s:\n",
+		len(steps), currentNetworkName(), from.Hex(), Output.Ether(total), policy)
+	for _, s := range steps {
+		fmt.Fprintf(os.Stderr, "  %d. %s\n", s.number, s.describe())
+	}
+}
+
+// count counts the outcomes of the steps.
+func (r *BatchReport) count() {
+	r.Succeeded, r.Failed, r.Skipped, r.Unconfirmed = 0, 0, 0, 0
+	for _, s := range r.Steps {
+		switch s.Status {
+		case "success", "would succeed":
+			r.Succeeded++
+		case "failed", "would fail":
+			r.Failed++
+		case "skipped":
+			r.Skipped++
+		case "sent, not confirmed":
+			r.Unconfirmed++
+		}
+	}
+}
+
+// writeReport counts the outcomes of the steps and writes the report to the file.
+func writeReport(path string, report *BatchReport) error {
+	report.count()
+	if err := writeJSON(path, report); err != nil {
+		return fmt.Errorf("can't write the report %s: %w", path, err)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(batchCmd)
+	batchCmd.AddCommand(batchRunCmd)
+	addWriteFlags(batchRunCmd)
+	batchRunCmd.Flags().String("on-failure", "", "stop or continue when a step fails, in place of on_failure of the plan")
+	batchRunCmd.Flags().String("report", "", "the file to write the outcome of every step to, by default the plan with .report.json in place of its extension")
+}
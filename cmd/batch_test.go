@@ -0,0 +1,273 @@
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	BKC "win/Code/BKC"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// writePlan writes the plan to a temporary file and returns its path.
+func writePlan(t *testing.T, plan string) string {
+	path := filepath.Join(t.TempDir(), "plan.yaml")
+	if err := ioutil.WriteFile(path, []byte(plan), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadPlan(t *testing.T) {
+	const validator = "0x089aa61131D80b7AF69083d2Ea7651e9667fC536"
+	tests := []struct {
+		name string
+		plan string
+		err  string
+	}{
+		{"valid", "on_failure: continue\nsteps:\n  - op: delegate\n    validator: " + validator + "\n    amount: 1.5\n  - op: add-reward\n    validator: active\n    amount: 1\n  - op: claim\n", ""},
+		{"no steps", "on_failure: stop\n", "has no steps"},
+		{"unknown op", "steps:\n  - op: stake\n    amount: 1\n", `unknown op "stake"`},
+		{"missing validator", "steps:\n  - op: delegate\n    amount: 1\n", "delegate needs a validator"},
+		{"invalid validator", "steps:\n  - op: undelegate\n    validator: 0x089a\n    amount: 1\n", `invalid validator "0x089a"`},
+		{"extra validator", "steps:\n  - op: topup\n    validator: " + validator + "\n    amount: 1\n", "topup takes no validator"},
+		{"missing amount", "steps:\n  - op: add-reward\n    validator: active\n", "add-reward needs an amount"},
+		{"extra amount", "steps:\n  - op: claim\n    amount: 1\n", "claim takes no amount"},
+		{"bad amount", "steps:\n  - op: fund\n    amount: ten\n", "step 1 of the plan"},
+		{"unknown key", "steps:\n  - op: fund\n    ammount: 1\n", "can't read the plan"},
+		{"unknown top-level key", "on_failur: stop\nsteps:\n  - op: claim\n", "can't read the plan"},
+	}
+	for _, tt := range tests {
+		_, err := readPlan(writePlan(t, tt.plan))
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: readPlan = %v", tt.name, err)
+		case tt.err == "":
+		case err == nil || !strings.Contains(err.Error(), tt.err):
+			t.Errorf("%s: readPlan = %v, want an error with %q", tt.name, err, tt.err)
+		case BKC.KindOf(err) != BKC.KindUsage:
+			t.Errorf("%s: readPlan = %v, want a usage error", tt.name, err)
+		}
+	}
+}
+
+func TestExpandPlan(t *testing.T) {
+	ctx := context.Background()
+	c := simulatedClient(t)
+	active, err := c.ActiveValidators(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan := Plan{Steps: []PlanStep{
+		{Op: "add-reward", Validator: "active", Amount: "1"},
+		{Op: "topup", Amount: "2"},
+	}}
+	steps, err := expandPlan(ctx, c, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != len(active)+1 {
+		t.Fatalf("the plan expands to %d steps, want one per validator of the %d active and the topup", len(steps), len(active))
+	}
+	for i, v := range active {
+		if s := steps[i]; s.number != i+1 || s.op != "add-reward" || s.validator != v.ConsensusAddress || s.amount.Cmp(oneEther) != 0 {
+			t.Errorf("step %d is %d. %s, want %d. add-reward 1 ether to %s", i, s.number, s.describe(), i+1, v.ConsensusAddress.Hex())
+		}
+	}
+	if s := steps[len(active)]; s.number != len(active)+1 || s.op != "topup" || s.validator != (common.Address{}) {
+		t.Errorf("the last step is %d. %s to %s, want %d. topup", s.number, s.describe(), s.validator.Hex(), len(active)+1)
+	}
+}
+
+// batchSteps expands the steps, which are all valid, for the simulated chain.
+func batchSteps(t *testing.T, c *BKC.Client, steps ...PlanStep) []batchStep {
+	expanded, err := expandPlan(context.Background(), c, Plan{Steps: steps})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return expanded
+}
+
+func TestCheckPlan(t *testing.T) {
+	ctx := context.Background()
+	c := simulatedClient(t)
+	// the delegator delegates 5 ether to the bonded validator 0
+	from := simChain.Delegators[0].Address
+	bonded, unbonded := simChain.Validators[0].Address.Hex(), simChain.Validators[2].Address.Hex()
+
+	tests := []struct {
+		name  string
+		steps []PlanStep
+		kind  BKC.Kind
+	}{
+		{"within the delegation", []PlanStep{
+			{Op: "undelegate", Validator: bonded, Amount: "2"},
+			{Op: "undelegate", Validator: bonded, Amount: "3"},
+		}, BKC.KindUnknown},
+		{"undelegations together beyond the delegation", []PlanStep{
+			{Op: "undelegate", Validator: bonded, Amount: "3"},
+			{Op: "undelegate", Validator: bonded, Amount: "3"},
+		}, BKC.KindRevert},
+		{"undelegation of what the plan delegates before", []PlanStep{
+			{Op: "delegate", Validator: unbonded, Amount: "3"},
+			{Op: "undelegate", Validator: unbonded, Amount: "2"},
+			{Op: "undelegate", Validator: unbonded, Amount: "1"},
+		}, BKC.KindUnknown},
+		{"undelegation beyond what the plan delegates before", []PlanStep{
+			{Op: "delegate", Validator: unbonded, Amount: "3"},
+			{Op: "undelegate", Validator: unbonded, Amount: "2"},
+			{Op: "undelegate", Validator: unbonded, Amount: "2"},
+		}, BKC.KindRevert},
+		{"undelegation before the delegation", []PlanStep{
+			{Op: "undelegate", Validator: unbonded, Amount: "1"},
+			{Op: "delegate", Validator: unbonded, Amount: "1"},
+		}, BKC.KindRevert},
+		{"delegation refused", []PlanStep{
+			{Op: "delegate", Validator: bonded, Amount: "1"},
+			// what the refused delegation would delegate isn't counted
+			{Op: "undelegate", Validator: bonded, Amount: "6"},
+		}, BKC.KindRevert},
+		{"beyond the balance", []PlanStep{
+			{Op: "delegate", Validator: unbonded, Amount: "600"},
+			{Op: "fund", Amount: "600"},
+		}, BKC.KindUsage},
+	}
+	for _, tt := range tests {
+		err := checkPlan(ctx, c, from, "plan.yaml", batchSteps(t, c, tt.steps...))
+		if tt.kind == BKC.KindUnknown {
+			if err != nil {
+				t.Errorf("%s: checkPlan = %v", tt.name, err)
+			}
+			continue
+		}
+		if BKC.KindOf(err) != tt.kind {
+			t.Errorf("%s: checkPlan = %v, want a %s error", tt.name, err, tt.kind)
+		}
+	}
+}
+
+// newBatchReport is the report of the steps before they are run.
+func newBatchReport(policy string, steps []batchStep) BatchReport {
+	report := BatchReport{Plan: "plan.yaml", OnFailure: policy}
+	for _, s := range steps {
+		report.Steps = append(report.Steps, s.result())
+	}
+	return report
+}
+
+// statuses are the statuses of the steps of the report.
+func statuses(report BatchReport) []string {
+	var statuses []string
+	for _, s := range report.Steps {
+		statuses = append(statuses, s.Status)
+	}
+	return statuses
+}
+
+func TestRunPlan(t *testing.T) {
+	ctx := context.Background()
+	tempDataDir(t)
+	c := simulatedClient(t)
+	from := simChain.Delegators[0].Address
+	signAs(t, from)
+	opts, err := transactOpts(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// only unbonded validators take delegations, so the second step reverts
+	steps := batchSteps(t, c,
+		PlanStep{Op: "delegate", Validator: simChain.Validators[2].Address.Hex(), Amount: "1"},
+		PlanStep{Op: "delegate", Validator: simChain.Validators[0].Address.Hex(), Amount: "1"},
+		PlanStep{Op: "delegate", Validator: simChain.Validators[2].Address.Hex(), Amount: "1"},
+	)
+
+	tests := []struct {
+		policy   string
+		statuses []string
+	}{
+		{"stop", []string{"success", "failed", "skipped"}},
+		{"continue", []string{"success", "failed", "success"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "plan.report.json")
+			result := newBatchReport(tt.policy, steps)
+			failure, err := runPlan(ctx, c, opts, tt.policy, path, steps, &result)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if BKC.KindOf(failure) != BKC.KindRevert {
+				t.Errorf("the run fails with %v, want the revert of step 2", failure)
+			}
+
+			var written BatchReport
+			if err := readJSON(path, &written); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(statuses(written), ", "); got != strings.Join(tt.statuses, ", ") {
+				t.Errorf("the steps are %s, want %s", got, strings.Join(tt.statuses, ", "))
+			}
+			succeeded := len(tt.statuses) - 1 - written.Skipped
+			if written.Succeeded != succeeded || written.Failed != 1 || written.Unconfirmed != 0 {
+				t.Errorf("the report counts %d succeeded and %d failed, want %d and 1", written.Succeeded, written.Failed, succeeded)
+			}
+			for i, s := range written.Steps {
+				if (s.Status == "success") != (s.Block != 0 && s.Tx != "") {
+					t.Errorf("step %d is %s in block %d with tx %q", i+1, s.Status, s.Block, s.Tx)
+				}
+			}
+			if s := written.Steps[1]; s.Error == "" || s.Hint == "" {
+				t.Errorf("the failed step has error %q and hint %q, want both", s.Error, s.Hint)
+			}
+		})
+	}
+}
+
+func TestDryRunPlan(t *testing.T) {
+	ctx := context.Background()
+	c := simulatedClient(t)
+	signAs(t, simChain.Delegators[0].Address)
+	opts, err := transactOpts(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := simChain.HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := batchSteps(t, c,
+		PlanStep{Op: "delegate", Validator: simChain.Validators[2].Address.Hex(), Amount: "1"},
+		PlanStep{Op: "delegate", Validator: simChain.Validators[0].Address.Hex(), Amount: "1"},
+	)
+
+	// the outcomes are only in the report, the steps say nothing on their own
+	stderr, err := ioutil.TempFile(t.TempDir(), "stderr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := os.Stderr
+	os.Stderr = stderr
+	result := newBatchReport("stop", steps)
+	failure := dryRunPlan(ctx, c, opts, steps, &result)
+	os.Stderr = saved
+	stderr.Close()
+
+	if BKC.KindOf(failure) != BKC.KindRevert {
+		t.Errorf("the dry run fails with %v, want the revert of step 2", failure)
+	}
+	if got := strings.Join(statuses(result), ", "); got != "would succeed, would fail" {
+		t.Errorf("the steps are %s, want would succeed, would fail", got)
+	}
+	if result.Steps[0].GasUsed == 0 {
+		t.Error("the step that would succeed has no estimated gas")
+	}
+	if out, err := ioutil.ReadFile(stderr.Name()); err != nil || len(out) != 0 {
+		t.Errorf("the dry run wrote %q to stderr (%v)", out, err)
+	}
+	if now, err := simChain.HeaderByNumber(ctx, nil); err != nil || now.Number.Cmp(head.Number) != 0 {
+		t.Errorf("the chain is at block %v (%v), it was at %v before the dry run", now.Number, err, head.Number)
+	}
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	BKC "win/Code/BKC"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var deployCmd = &cobra.Command{
+	Use:   "deploy",
+	Short: "deploy and init a new suite of the four contracts",
+	Long: `Deploys BKCValidatorSet, StakePool, SystemReward and ValidatorPool with the --from
+account, then calls init on each of them with the addresses of the others, waiting
+for every transaction to be mined. The addresses are written into the network
+profile (--network) as soon as each contract is sent, so a deployment that was
+interrupted is resumed by running deploy again. Contracts that are deployed and
+initialised already are left as they are, which makes deploy safe to repeat.
+
+A profile that doesn't exist yet is created with the endpoint given by --rpc.
+With --dry-run only the missing deployments are estimated, as the inits need
+the contracts to exist.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		handleError(latestOnly())
+		if viper.GetBool("simulated") {
+			handleError(BKC.Errorf(BKC.KindUsage, "deploy can't be used with --simulated, whose chain has the contracts deployed already"))
+		}
+		if unsignedOut != "" {
+			handleError(BKC.Errorf(BKC.KindUsage, "deploy can't be signed offline, each of its steps needs the contracts of the previous ones"))
+		}
+
+		name := currentNetworkName()
+		var n Network
+		switch {
+		case viper.IsSet("networks." + name):
+			var err error
+			n, err = loadNetwork(name)
+			handleError(err)
+		case name == defaultNetworkName:
+			// the built-in addresses are those of another deployment
+			n.RPC = localNetwork.RPC
+		case !viper.IsSet("rpc"):
+			handleError(BKC.Errorf(BKC.KindUsage, "network %s doesn't exist yet, give the endpoint of its node with --rpc", name))
+		}
+		c, err := dialNetwork(name, n)
+		handleError(err)
+		if n.RPC == "" {
+			n.RPC = profile.RPC
+		}
+		id, err := chainID(ctx, c)
+		handleError(err)
+		n.ChainID = id.Uint64()
+		opts, err := transactOpts(ctx, c)
+		handleError(err)
+		if dryRun {
+			dryRunDeploy(ctx, c, opts)
+			return
+		}
+
+		// other runs sending from the account wait until the suite is up
+		lock, err := lockNonce(ctx, n.ChainID, opts.From)
+		handleError(err)
+		defer lock.unlock()
+		nonce, err := lock.next(ctx, c)
+		handleError(err)
+		opts.Nonce = new(big.Int).SetUint64(nonce)
+
+		steps := []DeployStepView{}
+		sent := []JournalEntry{}
+		addrs, err := BKC.Deploy(ctx, c.Backend(), opts, c.Addresses(), func(step BKC.DeployStep) {
+			view := DeployStepView{Contract: step.Contract.String(), Action: step.Action, Status: "done already"}
+			if step.Tx != nil {
+				view.Tx = step.Tx.Hash().Hex()
+				view.Status = "sent"
+				fmt.Fprintf(os.Stderr, "Sent the %s of the %s contract, transaction %s\n", step.Action, step.Contract, view.Tx)
+				entry := newJournalEntry(step.Tx, opts.From, id)
+				record(entry)
+				sent = append(sent, entry)
+				lock.sent(step.Tx.Nonce())
+				opts.Nonce = new(big.Int).SetUint64(step.Tx.Nonce() + 1)
+			}
+			steps = append(steps, view)
+			if step.Action == "deploy" && step.Tx != nil {
+				handleError(saveNetwork(name, withAddresses(n, step.Addresses)))
+			}
+		})
+		for _, entry := range sent {
+			// Deploy has waited for them, unless it was interrupted
+			refreshEntry(ctx, c, entry)
+		}
+		handleError(err)
+		n = withAddresses(n, addrs)
+		handleError(saveNetwork(name, n))
+		fmt.Fprintln(os.Stderr, "Saved to network", name)
+
+		render(DeployResult{
+			Network: name,
+			ChainID: n.ChainID,
+			Contracts: Contracts{
+				ValidatorSet:  n.ValidatorSet,
+				StakePool:     n.StakePool,
+				SystemReward:  n.SystemReward,
+				ValidatorPool: n.ValidatorPool,
+			},
+			Steps: steps,
+		})
+	},
+}
+
+// DeployStepView is a step of deploy. Status is sent for the transactions of
+// this run and done already for the steps of an earlier one.
+type DeployStepView struct {
+	Contract string `json:"contract" yaml:"contract"`
+	Action   string `json:"action" yaml:"action"`
+	Status   string `json:"status" yaml:"status"`
+	Tx       string `json:"tx,omitempty" yaml:"tx,omitempty"`
+}
+
+// DeployResult is the suite brought up by deploy and the profile it is saved to.
+type DeployResult struct {
+	Network   string           `json:"network" yaml:"network"`
+	ChainID   uint64           `json:"chain_id" yaml:"chain_id"`
+	Contracts Contracts        `json:"contracts" yaml:"contracts"`
+	Steps     []DeployStepView `json:"steps" yaml:"steps"`
+}
+
+// dryRunDeploy shows the deployments deploy would send, with their estimated
+// gas. The inits can't be estimated before the contracts exist.
+func dryRunDeploy(ctx context.Context, c *BKC.Client, opts *bind.TransactOpts) {
+	head, err := c.Backend().HeaderByNumber(ctx, nil)
+	handleError(err)
+	opts.NoSend = true
+	results := []DryRunResult{}
+	_, err = BKC.Deploy(ctx, c.Backend(), opts, c.Addresses(), func(step BKC.DeployStep) {
+		if step.Tx == nil {
+			fmt.Fprintf(os.Stderr, "The %s contract is deployed already\n", step.Contract)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Would deploy the %s contract\n", step.Contract)
+		results = append(results, newDryRunResult(step.Tx, opts.From, step.Tx.Gas(), head.BaseFee))
+	})
+	handleError(err)
+	fmt.Fprintln(os.Stderr, "Dry run: nothing was sent. The inits can't be estimated before the contracts are deployed")
+	render(results)
+}
+
+// withAddresses sets the contract addresses of the profile. Zero ones are left empty.
+func withAddresses(n Network, addrs BKC.Addresses) Network {
+	hex := func(a common.Address) string {
+		if a == (common.Address{}) {
+			return ""
+		}
+		return a.Hex()
+	}
+	n.ValidatorSet = hex(addrs.ValidatorSet)
+	n.StakePool = hex(addrs.StakePool)
+	n.SystemReward = hex(addrs.SystemReward)
+	n.ValidatorPool = hex(addrs.ValidatorPool)
+	return n
+}
+
+func init() {
+	rootCmd.AddCommand(deployCmd)
+	addWriteFlags(deployCmd)
+}
@@ -0,0 +1,88 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	BKC "win/Code/BKC"
+	Discover "win/Code/Discover"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+)
+
+// discoverCmd represents the discover command
+var discoverCmd = &cobra.Command{
+	Use:   "discover [validator set address]",
+	Short: "find the other contracts from the validator set contract",
+	Long: `Reads the validator pool, system reward and stake pool addresses from the storage of
+the BKCValidatorSet contract (the one of the network profile by default) and checks that
+all four contracts point at each other. Use --save to write the result into a network profile.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
+		handleError(err)
+
+		start := profile.ValidatorSet
+		if len(args) == 1 {
+			start = args[0]
+		}
+		if !common.IsHexAddress(start) {
+			handleError(BKC.Errorf(BKC.KindUsage, "invalid validator set address %q", start))
+		}
+
+		addrs, err := Discover.Discover(cmd.Context(), c.Backend(), common.HexToAddress(start), c.Block())
+		handleError(err)
+
+		render(Contracts{
+			ValidatorSet:  addrs.ValidatorSet.Hex(),
+			StakePool:     addrs.StakePool.Hex(),
+			SystemReward:  addrs.SystemReward.Hex(),
+			ValidatorPool: addrs.ValidatorPool.Hex(),
+		})
+
+		name, err := cmd.Flags().GetString("save")
+		handleError(err)
+		if name == "" {
+			return
+		}
+		n, err := loadNetwork(name)
+		if err != nil {
+			// a new profile takes the endpoint of the current one
+			n = profile
+		}
+		n.ValidatorSet = addrs.ValidatorSet.Hex()
+		n.StakePool = addrs.StakePool.Hex()
+		n.SystemReward = addrs.SystemReward.Hex()
+		n.ValidatorPool = addrs.ValidatorPool.Hex()
+		handleError(saveNetwork(name, n))
+		fmt.Fprintln(os.Stderr, "Saved to network", name)
+	},
+}
+
+// Contracts is the output form of a set of contract addresses.
+type Contracts struct {
+	ValidatorSet  string `json:"validatorset" yaml:"validatorset"`
+	StakePool     string `json:"stakepool" yaml:"stakepool"`
+	SystemReward  string `json:"systemreward" yaml:"systemreward"`
+	ValidatorPool string `json:"vldpool" yaml:"vldpool"`
+}
+
+func init() {
+	rootCmd.AddCommand(discoverCmd)
+	discoverCmd.Flags().String("save", "", "write the addresses into this network profile")
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	BKC "win/Code/BKC"
+	Output "win/Code/Output"
+
+	"github.com/spf13/viper"
+)
+
+// The exit codes of the cli, one per kind of error.
+const (
+	exitOK             = 0
+	exitUnknown        = 1
+	exitUsage          = 2
+	exitConnection     = 3
+	exitNotInitialised = 4
+	exitNotFound       = 5
+	exitRevert         = 6
+)
+
+// exitCodesHelp documents the exit codes in the help of the root command.
+const exitCodesHelp = `Exit codes:
+  0  success
+  1  unknown error
+  2  usage: bad arguments, flags or configuration
+  3  connection: the node can't be reached or is on another chain
+  4  not-initialised: init hasn't been called on a contract
+  5  not-found: no such validator, index, block or contract
+  6  revert: the contract reverted the call`
+
+// ErrorView is the output form of an error when --output is json or yaml.
+type ErrorView struct {
+	Error ErrorDetail `json:"error" yaml:"error"`
+}
+
+type ErrorDetail struct {
+	Kind     string `json:"kind" yaml:"kind"`
+	ExitCode int    `json:"exit_code" yaml:"exit_code"`
+	Message  string `json:"message" yaml:"message"`
+	Reason   string `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Hint     string `json:"hint,omitempty" yaml:"hint,omitempty"`
+}
+
+// exitCode is the exit code of the kind of error.
+func exitCode(kind BKC.Kind) int {
+	switch kind {
+	case BKC.KindUsage:
+		return exitUsage
+	case BKC.KindConnection:
+		return exitConnection
+	case BKC.KindNotInitialised:
+		return exitNotInitialised
+	case BKC.KindNotFound:
+		return exitNotFound
+	case BKC.KindRevert:
+		return exitRevert
+	}
+	return exitUnknown
+}
+
+// usageError marks err as a usage error unless it already has a kind.
+func usageError(err error) error {
+	var e *BKC.Error
+	if err == nil || errors.As(err, &e) {
+		return err
+	}
+	return &BKC.Error{Kind: BKC.KindUsage, Err: err}
+}
+
+// handleError reports err and exits with the exit code of its kind. With --output
+// json or yaml the error is written to stdout as an error object, otherwise it
+// is written to stderr.
+func handleError(err error) {
+	if err == nil {
+		return
+	}
+	view := newErrorView(err)
+
+	switch format := viper.GetString("output"); format {
+	case Output.JSON, Output.YAML:
+		Output.Render(os.Stdout, format, view)
+	default:
+		fmt.Fprintln(os.Stderr, "Error:", view.Error.Message)
+		if view.Error.Hint != "" {
+			fmt.Fprintln(os.Stderr, "Hint:", view.Error.Hint)
+		}
+	}
+	os.Exit(view.Error.ExitCode)
+}
+
+// newErrorView classifies err and returns its error object.
+func newErrorView(err error) ErrorView {
+	err = BKC.Classify(err)
+	detail := ErrorDetail{Kind: BKC.KindUnknown.String(), ExitCode: exitUnknown, Message: err.Error()}
+	var e *BKC.Error
+	if errors.As(err, &e) {
+		detail.Kind = e.Kind.String()
+		detail.ExitCode = exitCode(e.Kind)
+		detail.Reason = e.Reason
+		detail.Hint = e.Hint
+	}
+	return ErrorView{Error: detail}
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"syscall"
+	"testing"
+	BKC "win/Code/BKC"
+	discover "win/Code/Discover"
+	Output "win/Code/Output"
+
+	"github.com/ethereum/go-ethereum"
+)
+
+// TestExitCodes pins the exit code of each kind of error, which scripts rely on,
+// and checks that the help of the root command lists them.
+func TestExitCodes(t *testing.T) {
+	tests := []struct {
+		kind BKC.Kind
+		code int
+	}{
+		{BKC.KindUnknown, 1},
+		{BKC.KindUsage, 2},
+		{BKC.KindConnection, 3},
+		{BKC.KindNotInitialised, 4},
+		{BKC.KindNotFound, 5},
+		{BKC.KindRevert, 6},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.kind); got != tt.code {
+			t.Errorf("exitCode(%v) = %d, want %d", tt.kind, got, tt.code)
+		}
+		if line := fmt.Sprintf("%d  %s", tt.code, tt.kind); !strings.Contains(exitCodesHelp, line) {
+			t.Errorf("the exit codes help doesn't list %q", line)
+		}
+	}
+}
+
+func TestErrorView(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		kind   string
+		code   int
+		reason string
+	}{
+		{"plain", errors.New("boom"), "unknown", 1, ""},
+		{"usage", usageError(errors.New("bad flag")), "usage", 2, ""},
+		{"connection refused", fmt.Errorf("dial: %w", syscall.ECONNREFUSED), "connection", 3, ""},
+		{"not init", fmt.Errorf("discover: %w", discover.ErrNotInit), "not-initialised", 4, ""},
+		{"not found", ethereum.NotFound, "not-found", 5, ""},
+		{"revert", errors.New("execution reverted: no way"), "revert", 6, "no way"},
+		{"kind kept", usageError(BKC.Errorf(BKC.KindNotFound, "no such validator")), "not-found", 5, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			view := newErrorView(tt.err)
+			want := ErrorDetail{Kind: tt.kind, ExitCode: tt.code, Message: tt.err.Error(), Reason: tt.reason}
+			if view.Error != want {
+				t.Errorf("newErrorView(%v) = %+v, want %+v", tt.err, view.Error, want)
+			}
+		})
+	}
+}
+
+// TestErrorViewJSON pins the field names of the error object written with
+// --output json.
+func TestErrorViewJSON(t *testing.T) {
+	var buf bytes.Buffer
+	Output.Render(&buf, Output.JSON, newErrorView(errors.New("execution reverted: no way")))
+	var got map[string]map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("%v in %s", err, buf.String())
+	}
+	want := map[string]interface{}{
+		"kind":      "revert",
+		"exit_code": float64(6),
+		"message":   "execution reverted: no way",
+		"reason":    "no way",
+	}
+	if len(got["error"]) != len(want) {
+		t.Errorf("error object %v, want %v", got["error"], want)
+	}
+	for k, v := range want {
+		if got["error"][k] != v {
+			t.Errorf("error.%s = %v, want %v", k, got["error"][k], v)
+		}
+	}
+}
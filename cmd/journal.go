@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+	BKC "win/Code/BKC"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// The journal is a JSON Lines file under the data directory recording every
+// transaction sent by the cli. It is only appended to: an entry is written when
+// the transaction is sent and again with its outcome, and the last entry of a
+// hash wins. Transactions of the simulated chain aren't recorded.
+
+// JournalEntry is a transaction of the journal, with the time it was sent.
+// Outcome is pending until the receipt is seen, then success or failed, or
+// replaced when another transaction of the signer used its nonce. Replaces is
+// the transaction a tx speedup or tx cancel was sent to replace.
+type JournalEntry struct {
+	Hash     string   `json:"hash" yaml:"hash"`
+	Time     string   `json:"time" yaml:"time"`
+	Network  string   `json:"network" yaml:"network"`
+	ChainID  uint64   `json:"chain_id" yaml:"chain_id"`
+	Command  string   `json:"command" yaml:"command"`
+	Args     []string `json:"args" yaml:"args"`
+	Signer   string   `json:"signer" yaml:"signer"`
+	Nonce    uint64   `json:"nonce" yaml:"nonce"`
+	To       string   `json:"to" yaml:"to"`
+	Outcome  string   `json:"outcome" yaml:"outcome"`
+	Error    string   `json:"error,omitempty" yaml:"error,omitempty"`
+	Block    uint64   `json:"block,omitempty" yaml:"block,omitempty"`
+	GasUsed  uint64   `json:"gas_used,omitempty" yaml:"gas_used,omitempty"`
+	Replaces string   `json:"replaces,omitempty" yaml:"replaces,omitempty"`
+}
+
+// invoked is the command being run, set before it runs.
+var invoked *cobra.Command
+
+// journalPath is journal.jsonl under the data directory.
+func journalPath() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "journal.jsonl"), nil
+}
+
+// newJournalEntry is the pending entry of a transaction the running command sends
+// on the chain id, as the node reports it when the profile doesn't set it.
+func newJournalEntry(tx *types.Transaction, signer common.Address, id *big.Int) JournalEntry {
+	entry := JournalEntry{
+		Hash:    tx.Hash().Hex(),
+		Time:    time.Now().UTC().Format(time.RFC3339),
+		Network: currentNetworkName(),
+		ChainID: id.Uint64(),
+		Signer:  signer.Hex(),
+		Nonce:   tx.Nonce(),
+		To:      "contract creation",
+		Outcome: "pending",
+	}
+	if tx.To() != nil {
+		entry.To = tx.To().Hex()
+	}
+	entry.Command, entry.Args = invocation()
+	return entry
+}
+
+// invocation is the path of the running command and its arguments, with the
+// flags that were set.
+func invocation() (string, []string) {
+	if invoked == nil {
+		return "", nil
+	}
+	args := append([]string{}, invoked.Flags().Args()...)
+	invoked.Flags().Visit(func(f *pflag.Flag) {
+		args = append(args, fmt.Sprintf("--%s=%s", f.Name, f.Value))
+	})
+	return invoked.CommandPath(), args
+}
+
+// withReceipt is the entry with the outcome of the receipt. err is the reason
+// of a failed transaction.
+func (e JournalEntry) withReceipt(receipt *types.Receipt, err error) JournalEntry {
+	e.Outcome = "success"
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		e.Outcome = "failed"
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	e.Block = receipt.BlockNumber.Uint64()
+	e.GasUsed = receipt.GasUsed
+	return e
+}
+
+// record appends the entry to the journal. The transaction is sent already,
+// so a journal that can't be written is only warned about.
+func record(entry JournalEntry) {
+	if simChain != nil {
+		return
+	}
+	if err := appendJournal(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: can't record transaction %s in the journal: %v\n", entry.Hash, err)
+	}
+}
+
+func appendJournal(entry JournalEntry) error {
+	path, err := journalPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readJournal returns the last entry of every hash, in the order the
+// transactions were sent.
+func readJournal() ([]JournalEntry, error) {
+	path, err := journalPath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := []JournalEntry{}
+	index := map[string]int{}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("journal %s, line %d: %v", path, line, err)
+		}
+		if i, ok := index[entry.Hash]; ok {
+			entries[i] = entry
+			continue
+		}
+		index[entry.Hash] = len(entries)
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// refreshEntry looks up the receipt of a pending entry and records its outcome.
+// The entry is returned unchanged while the transaction isn't mined. It is
+// refused when the network of the entry now points at another chain, which
+// would tell nothing about it. Entries recorded without a chain ID aren't checked.
+func refreshEntry(ctx context.Context, c *BKC.Client, entry JournalEntry) (JournalEntry, error) {
+	if entry.ChainID != 0 {
+		id, err := chainID(ctx, c)
+		if err != nil {
+			return entry, err
+		}
+		if id.Uint64() != entry.ChainID {
+			return entry, BKC.Errorf(BKC.KindConnection, "transaction %s was sent on chain %d, network %s is now chain %d", entry.Hash, entry.ChainID, entry.Network, id)
+		}
+	}
+	hash := common.HexToHash(entry.Hash)
+	receipt, err := c.Backend().TransactionReceipt(ctx, hash)
+	if err != nil && BKC.KindOf(err) == BKC.KindNotFound {
+		// a transaction whose nonce was used by another one is never mined. The
+		// receipt is looked up again in case it was mined meanwhile.
+		var mined uint64
+		if mined, err = c.Backend().NonceAt(ctx, common.HexToAddress(entry.Signer), nil); err != nil || mined <= entry.Nonce {
+			return entry, err
+		}
+		if receipt, err = c.Backend().TransactionReceipt(ctx, hash); err != nil && BKC.KindOf(err) == BKC.KindNotFound {
+			entry.Outcome = "replaced"
+			record(entry)
+			return entry, nil
+		}
+	}
+	if err != nil {
+		return entry, err
+	}
+	entry = entry.withReceipt(receipt, nil)
+	record(entry)
+	return entry, nil
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+	BKC "win/Code/BKC"
+	Simulated "win/Code/Simulated"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TestRefreshEntryChainID checks that a pending entry is only looked up on the
+// chain it was sent on.
+func TestRefreshEntryChainID(t *testing.T) {
+	ctx := context.Background()
+	c := simulatedClient(t)
+	pending := JournalEntry{
+		Hash:    common.HexToHash("0x01").Hex(),
+		Network: "dev",
+		Signer:  simChain.Deployer.Address.Hex(),
+		Nonce:   1000,
+		Outcome: "pending",
+	}
+
+	tests := []struct {
+		name    string
+		chainID uint64
+		refused bool
+	}{
+		{"same chain", Simulated.ChainID, false},
+		{"recorded without a chain ID", 0, false},
+		{"another chain", 5, true},
+	}
+	for _, tt := range tests {
+		entry := pending
+		entry.ChainID = tt.chainID
+		got, err := refreshEntry(ctx, c, entry)
+		if refused := err != nil && BKC.KindOf(err) == BKC.KindConnection; refused != tt.refused || !tt.refused && err != nil {
+			t.Errorf("%s: refreshEntry = %v, want refused %v", tt.name, err, tt.refused)
+		}
+		if got.Outcome != "pending" {
+			t.Errorf("%s: the entry is %s, want it left pending", tt.name, got.Outcome)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	BKC "win/Code/BKC"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+// networkCmd represents the network command
+var networkCmd = &cobra.Command{
+	Use:   "network",
+	Short: "manage the network profiles of the config file",
+	Long: `A network profile holds the RPC endpoint, the expected chain ID and the addresses
+of the four contracts. Profiles live under the networks key of the config file and
+are selected with --network, CLI_NETWORK or the network key of the config file.`,
+}
+
+var networkListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the known network profiles",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		current := currentNetworkName()
+		entries := []NetworkEntry{}
+		for _, name := range networkNames() {
+			entries = append(entries, NetworkEntry{Name: name, Active: name == current})
+		}
+		render(entries)
+	},
+}
+
+var networkShowCmd = &cobra.Command{
+	Use:   "show [name]",
+	Short: "show a network profile (the active one by default)",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := currentNetworkName()
+		if len(args) == 1 {
+			name = args[0]
+		}
+		n, err := loadNetwork(name)
+		handleError(err)
+		render(NetworkView{
+			Name:          name,
+			RPC:           n.RPC,
+			ChainID:       n.ChainID,
+			ValidatorSet:  n.ValidatorSet,
+			StakePool:     n.StakePool,
+			SystemReward:  n.SystemReward,
+			ValidatorPool: n.ValidatorPool,
+		})
+	},
+}
+
+var networkUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "make a network profile the default one",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		_, err := loadNetwork(args[0])
+		handleError(err)
+		handleError(updateConfig(func(config map[interface{}]interface{}) {
+			config["network"] = args[0]
+		}))
+		fmt.Println()
+		fmt.Println("Now using network", args[0])
+		fmt.Println()
+	},
+}
+
+// NetworkEntry is a line of the network list.
+type NetworkEntry struct {
+	Name   string `json:"name" yaml:"name"`
+	Active bool   `json:"active" yaml:"active"`
+}
+
+// NetworkView is the output form of a network profile. A chain ID of 0 is not checked.
+type NetworkView struct {
+	Name          string `json:"name" yaml:"name"`
+	RPC           string `json:"rpc" yaml:"rpc"`
+	ChainID       uint64 `json:"chain_id" yaml:"chain_id"`
+	ValidatorSet  string `json:"validatorset" yaml:"validatorset"`
+	StakePool     string `json:"stakepool" yaml:"stakepool"`
+	SystemReward  string `json:"systemreward" yaml:"systemreward"`
+	ValidatorPool string `json:"vldpool" yaml:"vldpool"`
+}
+
+func init() {
+	rootCmd.AddCommand(networkCmd)
+	networkCmd.AddCommand(networkListCmd)
+	networkCmd.AddCommand(networkShowCmd)
+	networkCmd.AddCommand(networkUseCmd)
+}
+
+// currentNetworkName is the profile selected by --network, CLI_NETWORK or the config file.
+func currentNetworkName() string {
+	if name := viper.GetString("network"); name != "" {
+		return name
+	}
+	return defaultNetworkName
+}
+
+// networkNames returns the names of all profiles, including the built-in local one.
+func networkNames() []string {
+	names := []string{}
+	hasLocal := false
+	for name := range viper.GetStringMap("networks") {
+		names = append(names, name)
+		if name == defaultNetworkName {
+			hasLocal = true
+		}
+	}
+	if !hasLocal {
+		names = append(names, defaultNetworkName)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadNetwork reads the named profile from the config file. The local profile
+// falls back to the built-in Ganache deployment.
+func loadNetwork(name string) (Network, error) {
+	key := "networks." + name
+	if !viper.IsSet(key) {
+		if name == defaultNetworkName {
+			return localNetwork, nil
+		}
+		return Network{}, BKC.Errorf(BKC.KindUsage, "unknown network %q, see the network list command", name)
+	}
+	var n Network
+	if err := viper.UnmarshalKey(key, &n); err != nil {
+		return Network{}, BKC.Errorf(BKC.KindUsage, "can't read network %q: %v", name, err)
+	}
+	return n, nil
+}
+
+// saveNetwork writes the profile into the config file, replacing any profile of the same name.
+func saveNetwork(name string, n Network) error {
+	return updateConfig(func(config map[interface{}]interface{}) {
+		networks, ok := config["networks"].(map[interface{}]interface{})
+		if !ok {
+			networks = map[interface{}]interface{}{}
+		}
+		networks[name] = n
+		config["networks"] = networks
+	})
+}
+
+// configPath is the config file in use, or $HOME/.cli.yaml when there is none yet.
+func configPath() (string, error) {
+	if path := viper.ConfigFileUsed(); path != "" {
+		return path, nil
+	}
+	if cfgFile != "" {
+		return cfgFile, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".cli.yaml"), nil
+}
+
+// updateConfig applies update to the raw content of the config file and writes it back.
+// The file is edited directly rather than through viper so that flags and environment
+// variables of the current run are not persisted.
+func updateConfig(update func(config map[interface{}]interface{})) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	config := map[interface{}]interface{}{}
+	data, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return BKC.Errorf(BKC.KindUsage, "can't parse config file %s: %v", path, err)
+	}
+	update(config)
+	out, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, out, 0644)
+}
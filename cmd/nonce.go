@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+	BKC "win/Code/BKC"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/prometheus/tsdb/fileutil"
+)
+
+// The nonce manager keeps, for every signer of every chain, the nonce after the
+// last transaction the cli sent, in nonces/<chain ID>-<address>.json under the
+// data directory. A node that is lagging or load balanced may not know yet of a
+// transaction sent by an earlier run, and reports a pending nonce that is used
+// already. The file is locked while a nonce is picked and its transaction sent,
+// so runs of the cli sending from the same account at once queue up.
+
+// nonceFile is the content of the nonce file of a signer.
+type nonceFile struct {
+	Next uint64 `json:"next"`
+}
+
+// nonceLock is the locked nonce file of a signer.
+type nonceLock struct {
+	path   string
+	signer common.Address
+	lock   fileutil.Releaser
+	stored uint64
+}
+
+// lockNonce locks the nonce file of the signer on the chain, waiting while
+// another run of the cli holds it.
+func lockNonce(ctx context.Context, chain uint64, signer common.Address) (*nonceLock, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return nil, err
+	}
+	base := filepath.Join(dir, "nonces", fmt.Sprintf("%d-%s", chain, signer.Hex()))
+	l := &nonceLock{path: base + ".json", signer: signer}
+	if err := os.MkdirAll(filepath.Dir(base), 0700); err != nil {
+		return nil, err
+	}
+	// an unusable file is reported rather than waited for
+	f, err := os.OpenFile(base+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+
+	for waiting := false; ; {
+		l.lock, _, err = fileutil.Flock(base + ".lock")
+		if err == nil {
+			break
+		}
+		if !lockHeld(err) {
+			return nil, fmt.Errorf("can't lock the nonce file %s: %w", base+".lock", err)
+		}
+		if !waiting {
+			fmt.Fprintf(os.Stderr, "Waiting for another run of the cli sending from %s\n", signer.Hex())
+			waiting = true
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	data, err := ioutil.ReadFile(l.path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	var stored nonceFile
+	if err == nil {
+		err = json.Unmarshal(data, &stored)
+	}
+	if err != nil {
+		l.unlock()
+		return nil, fmt.Errorf("nonce file %s: %v", l.path, err)
+	}
+	l.stored = stored.Next
+	return l, nil
+}
+
+// lockHeld tells whether the error of a lock attempt says that the lock is held,
+// rather than that the file can't be locked at all, e.g. on NFS.
+func lockHeld(err error) bool {
+	return errors.Is(err, syscall.EWOULDBLOCK) || errors.Is(err, syscall.EAGAIN)
+}
+
+// next is the nonce of the next transaction of the signer: the pending nonce of
+// the node, or the one after the last transaction sent by the cli when the node
+// doesn't know of it yet.
+func (l *nonceLock) next(ctx context.Context, c *BKC.Client) (uint64, error) {
+	pending, err := c.Backend().PendingNonceAt(ctx, l.signer)
+	if err != nil {
+		return 0, err
+	}
+	if l.stored > pending {
+		nonces := fmt.Sprintf("nonce %d", pending)
+		if l.stored-pending > 1 {
+			nonces = fmt.Sprintf("nonces %d to %d", pending, l.stored-1)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: the node doesn't know of %s of %s, sent by the cli. If they were dropped, free them with tx cancel\n", nonces, l.signer.Hex())
+		return l.stored, nil
+	}
+	return pending, nil
+}
+
+// sent records that a transaction with the nonce was sent. The transaction is
+// out already, so a nonce file that can't be written is only warned about.
+func (l *nonceLock) sent(nonce uint64) {
+	if nonce < l.stored {
+		return
+	}
+	l.stored = nonce + 1
+	data, err := json.Marshal(nonceFile{Next: l.stored})
+	if err == nil {
+		err = ioutil.WriteFile(l.path, data, 0600)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: can't record nonce %d of %s: %v\n", nonce, l.signer.Hex(), err)
+	}
+}
+
+// unlock lets other runs of the cli send from the signer.
+func (l *nonceLock) unlock() {
+	l.lock.Release()
+}
+
+// peekNonce is the nonce the next transaction of the signer gets, which isn't
+// recorded as sent.
+func peekNonce(ctx context.Context, c *BKC.Client, signer common.Address) (uint64, error) {
+	if simChain != nil {
+		return c.Backend().PendingNonceAt(ctx, signer)
+	}
+	id, err := chainID(ctx, c)
+	if err != nil {
+		return 0, err
+	}
+	lock, err := lockNonce(ctx, id.Uint64(), signer)
+	if err != nil {
+		return 0, err
+	}
+	defer lock.unlock()
+	return lock.next(ctx, c)
+}
+
+// buildWithNonce makes the transaction with build, and so sends it, with the
+// nonce of the nonce manager. The simulated chain starts over on every run, so
+// its nonces aren't managed, nor are those given by the caller.
+func buildWithNonce(ctx context.Context, c *BKC.Client, opts *bind.TransactOpts, build txBuilder) (*types.Transaction, error) {
+	if simChain != nil || opts.Nonce != nil {
+		return build(opts)
+	}
+	id, err := chainID(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	lock, err := lockNonce(ctx, id.Uint64(), opts.From)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.unlock()
+	nonce, err := lock.next(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	managed := *opts
+	managed.Nonce = new(big.Int).SetUint64(nonce)
+	tx, err := build(&managed)
+	if err != nil {
+		return nil, err
+	}
+	lock.sent(tx.Nonce())
+	return tx, nil
+}
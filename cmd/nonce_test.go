@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/viper"
+)
+
+// tempDataDir points the data directory at a temporary one.
+func tempDataDir(t *testing.T) {
+	viper.Set("datadir", t.TempDir())
+	t.Cleanup(func() { viper.Set("datadir", "") })
+}
+
+func TestNonceLock(t *testing.T) {
+	ctx := context.Background()
+	tempDataDir(t)
+	c := simulatedClient(t)
+	signer := simChain.Delegators[0].Address
+	pending, err := simChain.PendingNonceAt(ctx, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := lockNonce(ctx, 1337, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := first.next(ctx, c); err != nil || n != pending {
+		t.Fatalf("first next = %d, %v, want the pending nonce %d", n, err, pending)
+	}
+
+	// a second holder waits until the first one has sent and let go
+	locked := make(chan *nonceLock)
+	go func() {
+		second, err := lockNonce(ctx, 1337, signer)
+		if err != nil {
+			t.Error(err)
+		}
+		locked <- second
+	}()
+	select {
+	case <-locked:
+		t.Fatal("the second holder got the lock while the first one held it")
+	case <-time.After(300 * time.Millisecond):
+	}
+	first.sent(pending)
+	first.unlock()
+	second := <-locked
+	if second == nil {
+		t.FailNow()
+	}
+	defer second.unlock()
+
+	// the node doesn't know of the transaction sent by the first holder
+	if n, err := second.next(ctx, c); err != nil || n != pending+1 {
+		t.Errorf("second next = %d, %v, want %d after the nonce sent by the first holder", n, err, pending+1)
+	}
+	// an older nonce, e.g. of a replacement, doesn't move it back
+	second.sent(pending)
+	if n, err := second.next(ctx, c); err != nil || n != pending+1 {
+		t.Errorf("next after sending an older nonce = %d, %v, want %d", n, err, pending+1)
+	}
+}
+
+func TestNonceLockCancelled(t *testing.T) {
+	tempDataDir(t)
+	signer := common.HexToAddress("0x096e327b68ba7E8C65Bd368484abDc59bb9B3562")
+	held, err := lockNonce(context.Background(), 1337, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer held.unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if l, err := lockNonce(ctx, 1337, signer); err != context.DeadlineExceeded {
+		if l != nil {
+			l.unlock()
+		}
+		t.Errorf("lockNonce while held = %v, want %v", err, context.DeadlineExceeded)
+	}
+	// another chain has its own lock
+	other, err := lockNonce(ctx, 1, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.unlock()
+}
+
+func TestLockHeld(t *testing.T) {
+	tests := []struct {
+		err  error
+		held bool
+	}{
+		{syscall.EWOULDBLOCK, true},
+		{syscall.EAGAIN, true},
+		{fmt.Errorf("flock: %w", syscall.EWOULDBLOCK), true},
+		{syscall.ENOLCK, false},
+		{syscall.EACCES, false},
+	}
+	for _, tt := range tests {
+		if got := lockHeld(tt.err); got != tt.held {
+			t.Errorf("lockHeld(%v) = %v, want %v", tt.err, got, tt.held)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	BKC "win/Code/BKC"
+	Output "win/Code/Output"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Offline signing: a write command run with --unsigned-out builds its
+// transaction with the node and writes it to a file, tx sign signs the file
+// where the key is, without a node, and tx broadcast sends the signed file.
+
+// UnsignedTx is the file written by --unsigned-out. Amounts are in wei. The fee
+// is a gas price before London and a fee cap and a tip after it.
+type UnsignedTx struct {
+	Command        string   `json:"command" yaml:"command"`
+	Args           []string `json:"args" yaml:"args"`
+	ChainID        uint64   `json:"chain_id" yaml:"chain_id"`
+	From           string   `json:"from" yaml:"from"`
+	To             string   `json:"to,omitempty" yaml:"to,omitempty"`
+	Nonce          uint64   `json:"nonce" yaml:"nonce"`
+	Gas            uint64   `json:"gas" yaml:"gas"`
+	ValueWei       string   `json:"value_wei" yaml:"value_wei"`
+	GasPriceWei    string   `json:"gas_price_wei,omitempty" yaml:"gas_price_wei,omitempty"`
+	MaxFeeWei      string   `json:"max_fee_per_gas_wei,omitempty" yaml:"max_fee_per_gas_wei,omitempty"`
+	MaxPriorityWei string   `json:"max_priority_fee_per_gas_wei,omitempty" yaml:"max_priority_fee_per_gas_wei,omitempty"`
+	Data           string   `json:"data" yaml:"data"`
+}
+
+// SignedTx is the file written by tx sign. Raw is the signed transaction in its
+// binary encoding, the command that built it is kept for the record.
+type SignedTx struct {
+	Command string   `json:"command" yaml:"command"`
+	Args    []string `json:"args" yaml:"args"`
+	ChainID uint64   `json:"chain_id" yaml:"chain_id"`
+	From    string   `json:"from" yaml:"from"`
+	Nonce   uint64   `json:"nonce" yaml:"nonce"`
+	Hash    string   `json:"hash" yaml:"hash"`
+	Raw     string   `json:"raw" yaml:"raw"`
+}
+
+var txSignCmd = &cobra.Command{
+	Use:   "sign <unsigned.json>",
+	Short: "sign a transaction written by --unsigned-out, without a node",
+	Long: `Signs a transaction written by a write command with --unsigned-out, using the
+keystore or the external signer of --signer, and writes it to --out for tx
+broadcast. No node is needed, so the keys can stay on a machine that is
+offline. The transaction is shown before it is signed.
+
+The signer is the from of the file, --from must be the same when it is given.
+--out defaults to the file with .signed.json in place of .json.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		out, err := cmd.Flags().GetString("out")
+		handleError(err)
+		if out == "" {
+			out = strings.TrimSuffix(args[0], ".json") + ".signed.json"
+		}
+		var u UnsignedTx
+		handleError(readJSON(args[0], &u))
+		tx, err := u.transaction()
+		handleError(err)
+		from := common.HexToAddress(u.From)
+		if viper.GetString("from") != "" {
+			given, err := fromAddress()
+			handleError(err)
+			if given != from {
+				handleError(BKC.Errorf(BKC.KindUsage, "%s is to be signed by %s, not by --from %s", args[0], from.Hex(), given.Hex()))
+			}
+		}
+
+		to := "contract creation"
+		if tx.To() != nil {
+			to = tx.To().Hex()
+		}
+		fmt.Fprintf(os.Stderr, "Signing %s: nonce %d on chain ID %d from %s to %s, %s ether, gas %d, at most %s ether in fees, %d bytes of data\n",
+			strings.Join(append([]string{u.Command}, u.Args...), " "), tx.Nonce(), u.ChainID, from.Hex(), to,
+			Output.Ether(tx.Value()), tx.Gas(), Output.Ether(maxFee(tx)), len(tx.Data()))
+		signed, err := signOffline(tx, from, new(big.Int).SetUint64(u.ChainID))
+		handleError(err)
+		raw, err := signed.MarshalBinary()
+		handleError(err)
+
+		s := SignedTx{
+			Command: u.Command,
+			Args:    u.Args,
+			ChainID: u.ChainID,
+			From:    from.Hex(),
+			Nonce:   signed.Nonce(),
+			Hash:    signed.Hash().Hex(),
+			Raw:     hexutil.Encode(raw),
+		}
+		handleError(writeJSON(out, s))
+		fmt.Fprintf(os.Stderr, "Wrote the signed transaction to %s, send it with tx broadcast\n", out)
+		render(s)
+	},
+}
+
+var txBroadcastCmd = &cobra.Command{
+	Use:   "broadcast <signed.json>",
+	Short: "send a transaction signed by tx sign",
+	Long: `Sends a transaction signed by tx sign to the node of --network, records it in
+the journal and waits until it is mined, as the write commands do. The chain ID
+of the transaction must be the one of the network.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		if unsignedOut != "" {
+			handleError(BKC.Errorf(BKC.KindUsage, "--unsigned-out doesn't apply to a transaction that is signed already"))
+		}
+		var s SignedTx
+		handleError(readJSON(args[0], &s))
+
+		c, err := writeClient()
+		handleError(err)
+		id, err := chainID(ctx, c)
+		handleError(err)
+		tx, from, err := s.transaction(id)
+		if err != nil {
+			handleError(fmt.Errorf("%s: %w", args[0], err))
+		}
+		if receipt, err := c.Backend().TransactionReceipt(ctx, tx.Hash()); err == nil {
+			handleError(BKC.Errorf(BKC.KindUsage, "transaction %s is mined already, in block %v", tx.Hash().Hex(), receipt.BlockNumber))
+		}
+		opts := &bind.TransactOpts{From: from, Signer: unsigned, Context: ctx}
+		build := func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			if opts.NoSend {
+				return tx, nil
+			}
+			if err := c.Backend().SendTransaction(ctx, tx); err != nil {
+				return nil, err
+			}
+			return tx, nil
+		}
+		transact(ctx, c, opts, from, build)
+	},
+}
+
+// signOffline signs the transaction for the chain with the keystore or, with
+// --signer, with the external signer.
+func signOffline(tx *types.Transaction, from common.Address, id *big.Int) (*types.Transaction, error) {
+	if viper.GetString("signer") != "" {
+		signer, a, err := externalAccount(from)
+		if err != nil {
+			return nil, err
+		}
+		signed, err := signer.SignTx(a, tx, id)
+		if err != nil {
+			return nil, fmt.Errorf("the signer at %s: %w", viper.GetString("signer"), err)
+		}
+		if err := checkSigned(signed, id); err != nil {
+			return nil, err
+		}
+		return signed, nil
+	}
+	ks, a, err := keystoreAccount(from)
+	if err != nil {
+		return nil, err
+	}
+	pass, err := passphrase("Passphrase of "+from.Hex()+": ", false)
+	if err != nil {
+		return nil, err
+	}
+	signed, err := ks.SignTxWithPassphrase(a, pass, tx, id)
+	return signed, keystoreError(err)
+}
+
+// writeUnsigned builds the transaction made by build without signing it and
+// writes it to --unsigned-out. Its nonce is the next one of the signer, so a
+// transaction built before this one is broadcast gets the same nonce.
+func writeUnsigned(ctx context.Context, c *BKC.Client, opts *bind.TransactOpts, subject common.Address, build txBuilder) (UnsignedTx, error) {
+	nonce, err := peekNonce(ctx, c, opts.From)
+	if err != nil {
+		return UnsignedTx{}, err
+	}
+	id, err := chainID(ctx, c)
+	if err != nil {
+		return UnsignedTx{}, err
+	}
+	next := *opts
+	next.Nonce = new(big.Int).SetUint64(nonce)
+	tx, err := estimate(ctx, c, &next, subject, build)
+	if err != nil {
+		return UnsignedTx{}, err
+	}
+
+	u := UnsignedTx{
+		ChainID:  id.Uint64(),
+		From:     opts.From.Hex(),
+		Nonce:    tx.Nonce(),
+		Gas:      tx.Gas(),
+		ValueWei: Output.Wei(tx.Value()),
+		Data:     hexutil.Encode(tx.Data()),
+	}
+	u.Command, u.Args = invocation()
+	if tx.To() != nil {
+		u.To = tx.To().Hex()
+	}
+	if tx.Type() == types.DynamicFeeTxType {
+		u.MaxFeeWei = Output.Wei(tx.GasFeeCap())
+		u.MaxPriorityWei = Output.Wei(tx.GasTipCap())
+	} else {
+		u.GasPriceWei = Output.Wei(tx.GasPrice())
+	}
+	if err := writeJSON(unsignedOut, u); err != nil {
+		return UnsignedTx{}, err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote the unsigned transaction to %s, sign it with tx sign\n", unsignedOut)
+	return u, nil
+}
+
+// transaction is the unsigned transaction of the file.
+func (u UnsignedTx) transaction() (*types.Transaction, error) {
+	if u.ChainID == 0 {
+		return nil, BKC.Errorf(BKC.KindUsage, "the transaction has no chain_id")
+	}
+	if !common.IsHexAddress(u.From) {
+		return nil, BKC.Errorf(BKC.KindUsage, "invalid from %q", u.From)
+	}
+	var to *common.Address
+	if u.To != "" {
+		if !common.IsHexAddress(u.To) {
+			return nil, BKC.Errorf(BKC.KindUsage, "invalid to %q", u.To)
+		}
+		a := common.HexToAddress(u.To)
+		to = &a
+	}
+	data, err := hexutil.Decode(u.Data)
+	if err != nil {
+		return nil, BKC.Errorf(BKC.KindUsage, "invalid data: %v", err)
+	}
+	value, err := parseWei("value_wei", u.ValueWei)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.GasPriceWei != "" {
+		price, err := parseWei("gas_price_wei", u.GasPriceWei)
+		if err != nil {
+			return nil, err
+		}
+		return types.NewTx(&types.LegacyTx{Nonce: u.Nonce, GasPrice: price, Gas: u.Gas, To: to, Value: value, Data: data}), nil
+	}
+	feeCap, err := parseWei("max_fee_per_gas_wei", u.MaxFeeWei)
+	if err != nil {
+		return nil, err
+	}
+	tip, err := parseWei("max_priority_fee_per_gas_wei", u.MaxPriorityWei)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   new(big.Int).SetUint64(u.ChainID),
+		Nonce:     u.Nonce,
+		GasTipCap: tip,
+		GasFeeCap: feeCap,
+		Gas:       u.Gas,
+		To:        to,
+		Value:     value,
+		Data:      data,
+	}), nil
+}
+
+// transaction is the signed transaction of the file and its signer, which must
+// be the from of the file. It must be signed for the chain id, which a legacy
+// transaction without a chain ID isn't.
+func (s SignedTx) transaction(id *big.Int) (*types.Transaction, common.Address, error) {
+	raw, err := hexutil.Decode(s.Raw)
+	if err != nil {
+		return nil, common.Address{}, BKC.Errorf(BKC.KindUsage, "invalid raw transaction: %v", err)
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return nil, common.Address{}, BKC.Errorf(BKC.KindUsage, "invalid raw transaction: %v", err)
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return nil, common.Address{}, BKC.Errorf(BKC.KindUsage, "invalid signature: %v", err)
+	}
+	if common.IsHexAddress(s.From) && common.HexToAddress(s.From) != from {
+		return nil, common.Address{}, BKC.Errorf(BKC.KindUsage, "the transaction is signed by %s, not by %s", from.Hex(), s.From)
+	}
+	if tx.ChainId().Cmp(id) != 0 {
+		return nil, common.Address{}, BKC.Errorf(BKC.KindUsage, "the transaction is signed for chain ID %v but network %s is chain ID %v", tx.ChainId(), currentNetworkName(), id)
+	}
+	return tx, from, nil
+}
+
+// parseWei parses the amount in wei of the field.
+func parseWei(field, s string) (*big.Int, error) {
+	wei, ok := new(big.Int).SetString(s, 10)
+	if !ok || wei.Sign() < 0 {
+		return nil, BKC.Errorf(BKC.KindUsage, "invalid %s %q", field, s)
+	}
+	return wei, nil
+}
+
+// readJSON reads the file into v.
+func readJSON(path string, v interface{}) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return usageError(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return BKC.Errorf(BKC.KindUsage, "can't read %s: %v", path, err)
+	}
+	return nil
+}
+
+// writeJSON writes v, indented, to the file.
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+func init() {
+	txCmd.AddCommand(txSignCmd)
+	txCmd.AddCommand(txBroadcastCmd)
+	txSignCmd.Flags().String("out", "", "the file to write the signed transaction to")
+	addWriteFlags(txBroadcastCmd)
+}
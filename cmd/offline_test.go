@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/viper"
+)
+
+// offlineKeystore points the data directory at a temporary keystore holding a
+// new key, unlocked with CLI_PASSWORD, and returns its address.
+func offlineKeystore(t *testing.T) common.Address {
+	dir := t.TempDir()
+	viper.Set("datadir", dir)
+	viper.Set("password", "pw")
+	t.Cleanup(func() {
+		viper.Set("datadir", "")
+		viper.Set("password", "")
+	})
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// light scrypt, the keystore reads the parameters from the key file
+	ks := keystore.NewKeyStore(filepath.Join(dir, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+	a, err := ks.ImportECDSA(key, "pw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a.Address
+}
+
+// signFile signs the unsigned transaction as tx sign does, through the files.
+func signFile(t *testing.T, u UnsignedTx) SignedTx {
+	dir := t.TempDir()
+	var read UnsignedTx
+	if err := writeJSON(filepath.Join(dir, "unsigned.json"), u); err != nil {
+		t.Fatal(err)
+	}
+	if err := readJSON(filepath.Join(dir, "unsigned.json"), &read); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := read.transaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := signOffline(tx, common.HexToAddress(read.From), new(big.Int).SetUint64(read.ChainID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := SignedTx{ChainID: read.ChainID, From: read.From, Nonce: signed.Nonce(), Hash: signed.Hash().Hex(), Raw: hexutil.Encode(raw)}
+	var back SignedTx
+	if err := writeJSON(filepath.Join(dir, "signed.json"), s); err != nil {
+		t.Fatal(err)
+	}
+	if err := readJSON(filepath.Join(dir, "signed.json"), &back); err != nil {
+		t.Fatal(err)
+	}
+	return back
+}
+
+func TestOfflineRoundTrip(t *testing.T) {
+	from := offlineKeystore(t)
+	to := common.HexToAddress("0x62E41143418f8607D5B709f04C94D4e5529C0Fb1")
+	tests := []struct {
+		name string
+		u    UnsignedTx
+		typ  uint8
+	}{
+		{"legacy", UnsignedTx{
+			ChainID: 1337, From: from.Hex(), To: to.Hex(), Nonce: 7, Gas: 23400,
+			ValueWei: "1500000000000000000", GasPriceWei: "1000000000", Data: "0xb60d4288",
+		}, types.LegacyTxType},
+		{"dynamic fee", UnsignedTx{
+			ChainID: 1337, From: from.Hex(), To: to.Hex(), Nonce: 8, Gas: 23400,
+			ValueWei: "1500000000000000000", MaxFeeWei: "469840161", MaxPriorityWei: "1", Data: "0xb60d4288",
+		}, types.DynamicFeeTxType},
+		{"contract creation", UnsignedTx{
+			ChainID: 1337, From: from.Hex(), Nonce: 9, Gas: 100000,
+			ValueWei: "0", MaxFeeWei: "469840161", MaxPriorityWei: "1", Data: "0x6080",
+		}, types.DynamicFeeTxType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := signFile(t, tt.u)
+			tx, signer, err := s.transaction(big.NewInt(1337))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if signer != from {
+				t.Errorf("signer = %s, want %s", signer.Hex(), from.Hex())
+			}
+			if tx.Type() != tt.typ {
+				t.Errorf("type = %d, want %d", tx.Type(), tt.typ)
+			}
+			if tx.ChainId().Uint64() != 1337 {
+				t.Errorf("chain ID = %v, want 1337", tx.ChainId())
+			}
+			if tx.Hash().Hex() != s.Hash {
+				t.Errorf("hash = %s, the file says %s", tx.Hash().Hex(), s.Hash)
+			}
+			if (tx.To() == nil) != (tt.u.To == "") || (tx.To() != nil && *tx.To() != to) {
+				t.Errorf("to = %v, want %q", tx.To(), tt.u.To)
+			}
+			if tx.Nonce() != tt.u.Nonce || tx.Gas() != tt.u.Gas || tx.Value().String() != tt.u.ValueWei || hexutil.Encode(tx.Data()) != tt.u.Data {
+				t.Errorf("got nonce %d gas %d value %v data %x, want %+v", tx.Nonce(), tx.Gas(), tx.Value(), tx.Data(), tt.u)
+			}
+			if tt.typ == types.LegacyTxType && tx.GasPrice().String() != tt.u.GasPriceWei {
+				t.Errorf("gas price = %v, want %s", tx.GasPrice(), tt.u.GasPriceWei)
+			}
+			if tt.typ == types.DynamicFeeTxType && (tx.GasFeeCap().String() != tt.u.MaxFeeWei || tx.GasTipCap().String() != tt.u.MaxPriorityWei) {
+				t.Errorf("fee cap %v tip %v, want %s and %s", tx.GasFeeCap(), tx.GasTipCap(), tt.u.MaxFeeWei, tt.u.MaxPriorityWei)
+			}
+
+			if _, _, err := s.transaction(big.NewInt(1)); err == nil {
+				t.Error("a transaction signed for chain ID 1337 was accepted for chain ID 1")
+			}
+		})
+	}
+}
+
+func TestOfflineRefused(t *testing.T) {
+	from := offlineKeystore(t)
+	u := UnsignedTx{ChainID: 1337, From: from.Hex(), Nonce: 1, Gas: 21000, ValueWei: "0", GasPriceWei: "1", Data: "0x"}
+
+	noChain := u
+	noChain.ChainID = 0
+	if _, err := noChain.transaction(); err == nil {
+		t.Error("an unsigned transaction without a chain ID was accepted")
+	}
+
+	s := signFile(t, u)
+	other := s
+	other.From = common.HexToAddress("0x01").Hex()
+	if _, _, err := other.transaction(big.NewInt(1337)); err == nil {
+		t.Error("a transaction signed by another account than its from was accepted")
+	}
+
+	// a legacy transaction signed without a chain ID can be replayed on any chain
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unprotected, err := types.SignTx(types.NewTx(&types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(1), Gas: 21000, To: &from, Value: new(big.Int)}), types.HomesteadSigner{}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := unprotected.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := (SignedTx{Raw: hexutil.Encode(raw)}).transaction(big.NewInt(1337)); err == nil {
+		t.Error("a legacy transaction without a chain ID was accepted")
+	}
+}
@@ -16,9 +16,17 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"os"
+	"os/signal"
+	"strings"
+	BKC "win/Code/BKC"
+	Block "win/Code/Block"
+	Init "win/Code/Init"
+	Output "win/Code/Output"
+	Simulated "win/Code/Simulated"
+	ETHclient "win/client"
 
 	"github.com/spf13/cobra"
 
@@ -26,17 +34,45 @@ import (
 )
 
 var cfgFile string
+var rpcURL string
+var networkName string
+
+var blockSpec string
+var outputFormat string
+var simulatedMode bool
+var dataDirFlag string
+var keystoreDir string
+var passwordFile string
+var fromFlag string
+var signerEndpoint string
+
+// simChain is the chain started by newClient in --simulated mode.
+var simChain *Simulated.Chain
+
+// profile is the network profile resolved by newClient.
+var profile Network
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "cli",
 	Short: "A brief description of your application",
-	Long: `A longer description that spans multiple lines and likely contains
-examples and usage of using your application. For example:
+	Long: `Queries the BKC PoSA contracts: BKCValidatorSet, StakePool, ValidatorPool and
+SystemReward. The node and the contract addresses come from a network profile,
+see the network command.
 
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
+` + exitCodesHelp,
+	// errors are reported by handleError, with the exit code of their kind
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		invoked = cmd
+		for _, format := range Output.Formats {
+			if viper.GetString("output") == format {
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown output format %q, expected one of %s", viper.GetString("output"), strings.Join(Output.Formats, ", "))
+	},
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	// Run: func(cmd *cobra.Command, args []string) { },
@@ -45,7 +81,20 @@ to quickly create a Cobra application.`,
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	cobra.CheckErr(rootCmd.Execute())
+	// commands get a context that is cancelled on Ctrl-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	// commands report their own errors, so what cobra returns is a bad command line
+	cmd, err := rootCmd.ExecuteContextC(ctx)
+	if err != nil {
+		switch viper.GetString("output") {
+		case Output.JSON, Output.YAML:
+			handleError(usageError(err))
+		}
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		fmt.Fprintf(os.Stderr, "Run '%s --help' for usage.\n", cmd.CommandPath())
+		os.Exit(exitUsage)
+	}
 }
 
 func init() {
@@ -56,6 +105,25 @@ func init() {
 	// will be global for your application.
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.cli.yaml)")
+	rootCmd.PersistentFlags().StringVar(&rpcURL, "rpc", "", "the JSON-RPC endpoint of the node, overrides the rpc of the network profile (env CLI_RPC)")
+	viper.BindPFlag("rpc", rootCmd.PersistentFlags().Lookup("rpc"))
+	rootCmd.PersistentFlags().StringVar(&networkName, "network", "", "the network profile to use (env CLI_NETWORK, default \""+defaultNetworkName+"\")")
+	viper.BindPFlag("network", rootCmd.PersistentFlags().Lookup("network"))
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", Output.Table, "the output format: "+strings.Join(Output.Formats, ", ")+" (env CLI_OUTPUT)")
+	viper.BindPFlag("output", rootCmd.PersistentFlags().Lookup("output"))
+	rootCmd.PersistentFlags().BoolVar(&simulatedMode, "simulated", false, "run against an in-process chain with the four contracts deployed and seeded, see the simulation key of the config file (env CLI_SIMULATED)")
+	viper.BindPFlag("simulated", rootCmd.PersistentFlags().Lookup("simulated"))
+	rootCmd.PersistentFlags().StringVar(&dataDirFlag, "datadir", "", "the directory holding the state of the cli (env CLI_DATADIR, default $HOME/.cli)")
+	viper.BindPFlag("datadir", rootCmd.PersistentFlags().Lookup("datadir"))
+	rootCmd.PersistentFlags().StringVar(&keystoreDir, "keystore", "", "the keystore directory (env CLI_KEYSTORE, default keystore under --datadir)")
+	viper.BindPFlag("keystore", rootCmd.PersistentFlags().Lookup("keystore"))
+	rootCmd.PersistentFlags().StringVar(&passwordFile, "password-file", "", "read the passphrase of the account from the first line of this file (or env CLI_PASSWORD)")
+	viper.BindPFlag("password-file", rootCmd.PersistentFlags().Lookup("password-file"))
+	rootCmd.PersistentFlags().StringVar(&fromFlag, "from", "", "the account signing transactions (env CLI_FROM)")
+	viper.BindPFlag("from", rootCmd.PersistentFlags().Lookup("from"))
+	rootCmd.PersistentFlags().StringVar(&signerEndpoint, "signer", "", "sign with a Clef compatible external signer at this endpoint, http://... or ipc:///path, rather than the keystore (env CLI_SIGNER)")
+	viper.BindPFlag("signer", rootCmd.PersistentFlags().Lookup("signer"))
+	rootCmd.PersistentFlags().StringVar(&blockSpec, "block", "", "query the state at a block number, a block hash or an RFC3339 timestamp (default latest)")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
@@ -78,7 +146,8 @@ func initConfig() {
 		viper.SetConfigName(".cli")
 	}
 
-	viper.AutomaticEnv() // read in environment variables that match
+	viper.SetEnvPrefix("cli")
+	viper.AutomaticEnv() // read in environment variables that match, e.g. CLI_RPC
 
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil {
@@ -86,10 +155,122 @@ func initConfig() {
 	}
 }
 
-func handleError(err error) {
+// newClient resolves the network profile, dials its endpoint and binds its contracts.
+// The endpoint can be overridden by --rpc, CLI_RPC or the rpc key of the config file.
+// When the profile has a chain ID, the node must report the same one. Queries of the
+// client read the block given by --block. With --simulated the client is bound to a
+// fresh simulated chain instead.
+func newClient() (*BKC.Client, error) {
+	if viper.GetBool("simulated") {
+		return newSimulatedClient()
+	}
+	name := currentNetworkName()
+	n, err := loadNetwork(name)
+	if err != nil {
+		return nil, err
+	}
+	return dialNetwork(name, n)
+}
+
+// dialNetwork is newClient for the given profile, whose endpoint is overridden
+// as newClient says.
+func dialNetwork(name string, n Network) (*BKC.Client, error) {
+	if viper.IsSet("rpc") {
+		n.RPC = viper.GetString("rpc")
+	}
+	profile = n
+
+	addrs, err := n.Addresses()
+	if err != nil {
+		return nil, BKC.Errorf(BKC.KindUsage, "network %s: %v", name, err)
+	}
+
+	client, err := ETHclient.Client(n.RPC)
+	if err != nil {
+		return nil, err
+	}
+	if n.ChainID != 0 {
+		id, err := client.ChainID(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		if id.Uint64() != n.ChainID {
+			return nil, BKC.Errorf(BKC.KindConnection, "network %s expects chain ID %d but %s reports %v", name, n.ChainID, n.RPC, id)
+		}
+	}
+
+	number, err := Block.Resolve(context.Background(), client, blockSpec)
+	if err != nil {
+		if err = BKC.Classify(err); BKC.KindOf(err) == BKC.KindUnknown {
+			// not a node error, the --block value itself is wrong
+			err = usageError(err)
+		}
+		return nil, err
+	}
+	if number != nil {
+		fmt.Fprintln(os.Stderr, "Querying at block:", number)
+	}
+
+	c, err := BKC.NewClient(client, addrs)
+	if err != nil {
+		return nil, err
+	}
+	return c.AtBlock(number), nil
+}
+
+// newSimulatedClient starts a simulated chain seeded as the simulation key of the
+// config file says, e.g.
+//
+//	simulation:
+//	  balance: 1000       # ether of every account
+//	  validators: [50, 30, 20]  # stakes in ether
+//	  delegators:
+//	    - validator: 0    # index in validators
+//	      amount: 5
+//
+// and binds its contracts. Missing keys are taken from Simulated.DefaultConfig.
+func newSimulatedClient() (*BKC.Client, error) {
+	if blockSpec != "" && blockSpec != "latest" {
+		// the simulated backend only serves the latest state
+		return nil, BKC.Errorf(BKC.KindUsage, "--block can't be used with --simulated")
+	}
+	cfg := Simulated.DefaultConfig
+	if viper.IsSet("simulation.validators") {
+		cfg.Validators = nil
+	}
+	if viper.IsSet("simulation.delegators") {
+		cfg.Delegators = nil
+	}
+	if err := viper.UnmarshalKey("simulation", &cfg); err != nil {
+		return nil, BKC.Errorf(BKC.KindUsage, "can't read the simulation config: %v", err)
+	}
+	chain, err := Simulated.New(context.Background(), cfg)
 	if err != nil {
-		log.Fatal(err)
+		return nil, BKC.Errorf(BKC.KindUsage, "can't start the simulated chain: %v", err)
+	}
+	simChain = chain
+	profile = Network{
+		RPC:           "simulated",
+		ChainID:       Simulated.ChainID,
+		ValidatorSet:  chain.Addresses.ValidatorSet.Hex(),
+		StakePool:     chain.Addresses.StakePool.Hex(),
+		SystemReward:  chain.Addresses.SystemReward.Hex(),
+		ValidatorPool: chain.Addresses.ValidatorPool.Hex(),
 	}
+	fmt.Fprintf(os.Stderr, "Using a simulated chain with %d validators and %d delegators\n", len(chain.Validators), len(chain.Delegators))
+	return BKC.NewClient(chain, chain.Addresses)
+}
+
+// GetInitStatus reads the alreadyInit field of a contract.
+func GetInitStatus(ctx context.Context, c *BKC.Client, contract BKC.Contract) Init.Status {
+	alreadyInit, err := c.AlreadyInit(ctx, contract)
+	handleError(err)
+	return Init.Status{Contract: contract.String(), AlreadyInit: alreadyInit}
+}
+
+// render writes the result of a query to stdout in the format chosen by --output.
+func render(result interface{}) {
+	handleError(Output.Render(os.Stdout, viper.GetString("output"), result))
 }
 
 var consensusAddress string
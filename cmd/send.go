@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	BKC "win/Code/BKC"
+	Output "win/Code/Output"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/cobra"
+)
+
+// TxResult is the output form of a mined transaction.
+type TxResult struct {
+	Hash          string `json:"hash" yaml:"hash"`
+	From          string `json:"from" yaml:"from"`
+	Status        string `json:"status" yaml:"status"`
+	Block         uint64 `json:"block" yaml:"block"`
+	GasUsed       uint64 `json:"gas_used" yaml:"gas_used"`
+	Confirmations uint64 `json:"confirmations" yaml:"confirmations"`
+}
+
+// writeClient is the client of the write commands, which act on the latest block.
+func writeClient() (*BKC.Client, error) {
+	if err := latestOnly(); err != nil {
+		return nil, err
+	}
+	return newClient()
+}
+
+// latestOnly refuses --block, which only applies to queries.
+func latestOnly() error {
+	if blockSpec != "" && blockSpec != "latest" {
+		return BKC.Errorf(BKC.KindUsage, "--block only applies to queries")
+	}
+	return nil
+}
+
+// dryRun is --dry-run of the write commands.
+var dryRun bool
+
+// confirmations is --confirmations of the write commands.
+var confirmations uint64
+
+// unsignedOut is --unsigned-out of the write commands.
+var unsignedOut string
+
+// addWriteFlags adds the flags shared by the commands that send transactions.
+func addWriteFlags(cmds ...*cobra.Command) {
+	for _, cmd := range cmds {
+		cmd.Flags().BoolVar(&dryRun, "dry-run", false, "run the transaction with eth_call and estimate its fee, without sending it")
+		cmd.Flags().Uint64Var(&confirmations, "confirmations", 1, "wait until this many blocks, the one of the transaction included, are mined")
+		cmd.Flags().StringVar(&unsignedOut, "unsigned-out", "", "write the transaction, unsigned, to this file for tx sign rather than sending it")
+	}
+}
+
+// txBuilder makes a transaction with the bindings, e.g. c.ValidatorPool.ValidatorTopUp.
+type txBuilder func(opts *bind.TransactOpts) (*types.Transaction, error)
+
+// preview shows the transaction made by build instead of sending it: simulated
+// with --dry-run, or written unsigned to the file of --unsigned-out. It tells
+// whether it did, otherwise the transaction is to be sent.
+func preview(ctx context.Context, c *BKC.Client, opts *bind.TransactOpts, subject common.Address, build txBuilder) (bool, error) {
+	var result interface{}
+	var err error
+	switch {
+	case dryRun:
+		result, err = simulate(ctx, c, opts, subject, build)
+		if err == nil {
+			fmt.Fprintln(os.Stderr, "Dry run: the transaction would succeed, nothing was sent")
+		}
+	case unsignedOut != "":
+		result, err = writeUnsigned(ctx, c, opts, subject, build)
+	default:
+		return false, nil
+	}
+	if err != nil {
+		return true, err
+	}
+	render(result)
+	return true, nil
+}
+
+// previewOrSend previews the transaction made by build with --dry-run or
+// --unsigned-out, or else sends it. The receipt is nil when it was previewed,
+// and the command has nothing more to show.
+func previewOrSend(ctx context.Context, c *BKC.Client, opts *bind.TransactOpts, subject common.Address, build txBuilder) (*types.Receipt, error) {
+	if previewed, err := preview(ctx, c, opts, subject, build); previewed {
+		return nil, err
+	}
+	return send(ctx, c, opts, subject, build)
+}
+
+// transact is previewOrSend for the commands whose outcome is the transaction
+// itself, which is shown once mined.
+func transact(ctx context.Context, c *BKC.Client, opts *bind.TransactOpts, subject common.Address, build txBuilder) {
+	receipt, err := previewOrSend(ctx, c, opts, subject, build)
+	handleError(err)
+	if receipt != nil {
+		render(newTxResult(receipt, opts.From))
+	}
+}
+
+// send signs and sends the transaction made by build with the nonce of the nonce
+// manager, records it in the journal and waits until it is mined with
+// --confirmations. Reverts are explained for subject, the validator whose queues
+// tell when the call can be retried. --dry-run and --unsigned-out are left to
+// previewOrSend.
+func send(ctx context.Context, c *BKC.Client, opts *bind.TransactOpts, subject common.Address, build txBuilder) (*types.Receipt, error) {
+	_, receipt, err := sendTx(ctx, c, opts, subject, build)
+	return receipt, err
+}
+
+// sendTx is send, which also returns the transaction once it is sent. When the
+// wait for it fails, it comes with the error and no receipt, and may still be
+// mined.
+func sendTx(ctx context.Context, c *BKC.Client, opts *bind.TransactOpts, subject common.Address, build txBuilder) (*types.Transaction, *types.Receipt, error) {
+	id, err := chainID(ctx, c)
+	if err != nil {
+		return nil, nil, err
+	}
+	tx, err := buildWithNonce(ctx, c, opts, build)
+	if err != nil {
+		return nil, nil, c.Explain(ctx, err, subject)
+	}
+	fmt.Fprintln(os.Stderr, "Sent transaction", tx.Hash().Hex(), "waiting for it to be mined")
+	entry := newJournalEntry(tx, opts.From, id)
+	record(entry)
+
+	receipt, err := waitConfirmed(ctx, c, tx)
+	if err != nil {
+		return tx, nil, err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		err = c.Explain(ctx, c.FailedError(ctx, tx, opts.From, receipt), subject)
+	}
+	record(entry.withReceipt(receipt, err))
+	return tx, receipt, err
+}
+
+// estimate builds the transaction made by build without sending it, which fills
+// in its nonce, gas price and estimated gas. Reverts are explained as in send.
+func estimate(ctx context.Context, c *BKC.Client, opts *bind.TransactOpts, subject common.Address, build txBuilder) (*types.Transaction, error) {
+	dry := *opts
+	dry.NoSend = true
+	tx, err := build(&dry)
+	if err != nil {
+		return nil, c.Explain(ctx, err, subject)
+	}
+	return tx, nil
+}
+
+// simulate builds the transaction made by build without sending it and runs it
+// with eth_call. The gas limit of the latest block is used to build it, so that
+// a revert is reported by the call, with its reason, rather than by the gas
+// estimation of the bindings.
+func simulate(ctx context.Context, c *BKC.Client, opts *bind.TransactOpts, subject common.Address, build txBuilder) (DryRunResult, error) {
+	head, err := c.Backend().HeaderByNumber(ctx, nil)
+	if err != nil {
+		return DryRunResult{}, err
+	}
+	dry := *opts
+	dry.NoSend = true
+	if dry.GasLimit == 0 {
+		dry.GasLimit = head.GasLimit
+	}
+	tx, err := build(&dry)
+	if err != nil {
+		return DryRunResult{}, c.Explain(ctx, err, subject)
+	}
+	gas, err := c.Simulate(ctx, tx, opts.From)
+	if err != nil {
+		return DryRunResult{}, c.Explain(ctx, err, subject)
+	}
+	return newDryRunResult(tx, opts.From, gas, head.BaseFee), nil
+}
+
+// DryRunResult is a transaction that was simulated rather than sent. The fee is
+// the estimated gas at the gas price the latest block asks, the max fee the
+// estimated gas at the fee cap.
+type DryRunResult struct {
+	From        string `json:"from" yaml:"from"`
+	To          string `json:"to" yaml:"to"`
+	ValueEther  string `json:"value_ether" yaml:"value_ether"`
+	Nonce       uint64 `json:"nonce" yaml:"nonce"`
+	Gas         uint64 `json:"gas" yaml:"gas"`
+	GasPriceWei string `json:"gas_price_wei" yaml:"gas_price_wei"`
+	FeeEther    string `json:"fee_ether" yaml:"fee_ether"`
+	MaxFeeEther string `json:"max_fee_ether" yaml:"max_fee_ether"`
+}
+
+// newDryRunResult is the output form of the transaction with the estimated gas.
+// baseFee is the one of the latest block, nil before London.
+func newDryRunResult(tx *types.Transaction, from common.Address, gas uint64, baseFee *big.Int) DryRunResult {
+	price := tx.GasPrice()
+	if baseFee != nil && tx.Type() == types.DynamicFeeTxType {
+		price = new(big.Int).Add(baseFee, tx.GasTipCap())
+		if price.Cmp(tx.GasFeeCap()) > 0 {
+			price = tx.GasFeeCap()
+		}
+	}
+	to := "contract creation"
+	if tx.To() != nil {
+		to = tx.To().Hex()
+	}
+	limit := new(big.Int).SetUint64(gas)
+	return DryRunResult{
+		From:        from.Hex(),
+		To:          to,
+		ValueEther:  Output.Ether(tx.Value()),
+		Nonce:       tx.Nonce(),
+		Gas:         gas,
+		GasPriceWei: Output.Wei(price),
+		FeeEther:    Output.Ether(new(big.Int).Mul(limit, price)),
+		MaxFeeEther: Output.Ether(new(big.Int).Mul(limit, tx.GasFeeCap())),
+	}
+}
+
+// maxFee is the most the transaction can cost in fees, its gas times its gas
+// price, or its fee cap for dynamic fee transactions.
+func maxFee(tx *types.Transaction) *big.Int {
+	return new(big.Int).Mul(new(big.Int).SetUint64(tx.Gas()), tx.GasFeeCap())
+}
+
+// newTxResult is the output form of the receipt of a transaction sent by from.
+func newTxResult(receipt *types.Receipt, from common.Address) TxResult {
+	status := "success"
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		status = "failed"
+	}
+	return TxResult{
+		Hash:          receipt.TxHash.Hex(),
+		From:          from.Hex(),
+		Status:        status,
+		Block:         receipt.BlockNumber.Uint64(),
+		GasUsed:       receipt.GasUsed,
+		Confirmations: confirmations,
+	}
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+	BKC "win/Code/BKC"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/spf13/viper"
+)
+
+var oneEther = big.NewInt(params.Ether)
+
+// simulatedClient starts the default simulated chain as --simulated does.
+func simulatedClient(t *testing.T) *BKC.Client {
+	c, err := newSimulatedClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		simChain.Close()
+		simChain = nil
+		profile = Network{}
+	})
+	return c
+}
+
+// signAs makes the commands sign with the account, as --from does.
+func signAs(t *testing.T, from common.Address) {
+	viper.Set("from", from.Hex())
+	t.Cleanup(func() { viper.Set("from", "") })
+}
+
+// topUp is the builder of vldpool topup, which fails the test when it is asked
+// to send.
+func topUp(t *testing.T, c *BKC.Client, amount *big.Int) txBuilder {
+	return func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		if !opts.NoSend {
+			t.Error("the transaction was sent")
+		}
+		opts.Value = amount
+		return c.ValidatorPool.ValidatorTopUp(opts)
+	}
+}
+
+// TestPreview checks that --dry-run and --unsigned-out never send, and that a
+// dry run of a reverting transaction fails with the exit code of a revert.
+func TestPreview(t *testing.T) {
+	ctx := context.Background()
+	c := simulatedClient(t)
+	bonded, unbonded := simChain.Validators[0].Address, simChain.Validators[2].Address
+	head, err := simChain.HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dryRun = true
+	t.Cleanup(func() { dryRun = false })
+	tests := []struct {
+		name string
+		from common.Address
+		code int
+	}{
+		{"would succeed", unbonded, exitOK},
+		// only unbonded validators can top up
+		{"would revert", bonded, exitRevert},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signAs(t, tt.from)
+			opts, err := transactOpts(ctx, c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			receipt, err := previewOrSend(ctx, c, opts, tt.from, topUp(t, c, oneEther))
+			if receipt != nil {
+				t.Errorf("a dry run returned the receipt of %s", receipt.TxHash.Hex())
+			}
+			code := exitOK
+			if err != nil {
+				code = exitCode(BKC.KindOf(err))
+			}
+			if code != tt.code {
+				t.Errorf("dry run exits with %d (%v), want %d", code, err, tt.code)
+			}
+		})
+	}
+
+	dryRun = false
+	unsignedOut = filepath.Join(t.TempDir(), "unsigned.json")
+	t.Cleanup(func() { unsignedOut = "" })
+	signAs(t, unbonded)
+	opts, err := transactOpts(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt, err := previewOrSend(ctx, c, opts, unbonded, topUp(t, c, oneEther)); receipt != nil || err != nil {
+		t.Errorf("--unsigned-out returned %v, %v", receipt, err)
+	}
+	var u UnsignedTx
+	if err := readJSON(unsignedOut, &u); err != nil {
+		t.Fatal(err)
+	}
+	if u.From != unbonded.Hex() || u.ValueWei != oneEther.String() {
+		t.Errorf("unsigned transaction from %s of %s wei, want from %s of %s wei", u.From, u.ValueWei, unbonded.Hex(), oneEther)
+	}
+
+	if now, err := simChain.HeaderByNumber(ctx, nil); err != nil || now.Number.Cmp(head.Number) != 0 {
+		t.Errorf("the chain is at block %v (%v), it was at %v before the previews", now.Number, err, head.Number)
+	}
+}
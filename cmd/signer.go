@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	BKC "win/Code/BKC"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/external"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/console/prompt"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/viper"
+)
+
+// dataDir is where the cli keeps its state, --datadir or $HOME/.cli.
+func dataDir() (string, error) {
+	if dir := viper.GetString("datadir"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".cli"), nil
+}
+
+// openKeystore opens the keystore directory, --keystore or keystore under the data directory.
+func openKeystore() (*keystore.KeyStore, error) {
+	dir := viper.GetString("keystore")
+	if dir == "" {
+		data, err := dataDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(data, "keystore")
+	}
+	return keystore.NewKeyStore(dir, keystore.StandardScryptN, keystore.StandardScryptP), nil
+}
+
+// passphrase reads the passphrase from the first line of --password-file, from
+// CLI_PASSWORD or, when stdin is a terminal, from a prompt. A new passphrase is
+// asked twice.
+func passphrase(message string, confirm bool) (string, error) {
+	if path := viper.GetString("password-file"); path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", usageError(err)
+		}
+		return strings.TrimRight(strings.SplitN(string(data), "\n", 2)[0], "\r"), nil
+	}
+	if viper.IsSet("password") {
+		return viper.GetString("password"), nil
+	}
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return "", BKC.Errorf(BKC.KindUsage, "no passphrase, use --password-file or CLI_PASSWORD")
+	}
+	pass, err := prompt.Stdin.PromptPassword(message)
+	if err != nil {
+		return "", err
+	}
+	if confirm {
+		again, err := prompt.Stdin.PromptPassword("Repeat the passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if pass != again {
+			return "", BKC.Errorf(BKC.KindUsage, "the passphrases don't match")
+		}
+	}
+	return pass, nil
+}
+
+// fromAddress is the signer given by --from or CLI_FROM.
+func fromAddress() (common.Address, error) {
+	from := viper.GetString("from")
+	if from == "" {
+		return common.Address{}, BKC.Errorf(BKC.KindUsage, "no signer, use --from to give the account to sign with")
+	}
+	if !common.IsHexAddress(from) {
+		return common.Address{}, BKC.Errorf(BKC.KindUsage, "invalid --from address %q", from)
+	}
+	return common.HexToAddress(from), nil
+}
+
+// chainID is the chain ID of the network profile, or the one reported by the node.
+func chainID(ctx context.Context, c *BKC.Client) (*big.Int, error) {
+	if profile.ChainID != 0 {
+		return new(big.Int).SetUint64(profile.ChainID), nil
+	}
+	reader, ok := c.Backend().(interface {
+		ChainID(ctx context.Context) (*big.Int, error)
+	})
+	if !ok {
+		return nil, BKC.Errorf(BKC.KindConnection, "the node can't report its chain ID, set chainid in the network profile")
+	}
+	return reader.ChainID(ctx)
+}
+
+// keystoreAccount finds the account in the keystore.
+func keystoreAccount(from common.Address) (*keystore.KeyStore, accounts.Account, error) {
+	ks, err := openKeystore()
+	if err != nil {
+		return nil, accounts.Account{}, err
+	}
+	a, err := ks.Find(accounts.Account{Address: from})
+	if err != nil {
+		return nil, accounts.Account{}, keystoreError(err)
+	}
+	return ks, a, nil
+}
+
+// dialSigner connects to the Clef compatible signer of --signer. An ipc://
+// endpoint is the path of its socket.
+func dialSigner() (*external.ExternalSigner, error) {
+	endpoint := viper.GetString("signer")
+	signer, err := external.NewExternalSigner(strings.TrimPrefix(endpoint, "ipc://"))
+	if err != nil {
+		return nil, BKC.Errorf(BKC.KindConnection, "can't reach the signer at %s: %v", endpoint, err)
+	}
+	return signer, nil
+}
+
+// externalAccount finds the account in the signer of --signer, which may ask
+// its user to approve listing the accounts.
+func externalAccount(from common.Address) (*external.ExternalSigner, accounts.Account, error) {
+	signer, err := dialSigner()
+	if err != nil {
+		return nil, accounts.Account{}, err
+	}
+	for _, a := range signer.Accounts() {
+		if a.Address == from {
+			return signer, a, nil
+		}
+	}
+	return nil, accounts.Account{}, BKC.Errorf(BKC.KindNotFound, "the signer at %s has no account %s, or didn't list it", viper.GetString("signer"), from.Hex())
+}
+
+// checkSigned checks that the external signer, which signs with the chain ID it
+// was started with, signed for the chain ID of the network.
+func checkSigned(tx *types.Transaction, id *big.Int) error {
+	if tx.ChainId().Cmp(id) != 0 {
+		return BKC.Errorf(BKC.KindUsage, "the signer at %s signed for chain ID %v, but the chain ID is %v", viper.GetString("signer"), tx.ChainId(), id)
+	}
+	return nil
+}
+
+// transactOpts signs with the --from account. With --signer the external signer
+// signs, and the key never leaves it. Otherwise with --simulated the seeded
+// accounts sign without a keystore, and other accounts are unlocked from the
+// keystore with the passphrase. With --dry-run nothing is sent, and with
+// --unsigned-out the transaction is signed elsewhere, so nothing is signed.
+func transactOpts(ctx context.Context, c *BKC.Client) (*bind.TransactOpts, error) {
+	from, err := fromAddress()
+	if err != nil {
+		return nil, err
+	}
+	if dryRun || unsignedOut != "" {
+		return &bind.TransactOpts{From: from, Signer: unsigned, Context: ctx}, nil
+	}
+	if viper.GetString("signer") != "" {
+		return externalOpts(ctx, c, from)
+	}
+	if simChain != nil {
+		for _, a := range simChain.Accounts() {
+			if a.Address == from {
+				return a.TransactOpts(ctx), nil
+			}
+		}
+	}
+
+	id, err := chainID(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	ks, a, err := keystoreAccount(from)
+	if err != nil {
+		return nil, err
+	}
+	pass, err := passphrase("Passphrase of "+from.Hex()+": ", false)
+	if err != nil {
+		return nil, err
+	}
+	if err := ks.Unlock(a, pass); err != nil {
+		return nil, keystoreError(err)
+	}
+	opts, err := bind.NewKeyStoreTransactorWithChainID(ks, a, id)
+	if err != nil {
+		return nil, err
+	}
+	opts.Context = ctx
+	return opts, nil
+}
+
+// externalOpts signs with the account of the external signer. Each transaction
+// is approved on the signer.
+func externalOpts(ctx context.Context, c *BKC.Client, from common.Address) (*bind.TransactOpts, error) {
+	id, err := chainID(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	signer, a, err := externalAccount(from)
+	if err != nil {
+		return nil, err
+	}
+	opts := bind.NewClefTransactor(signer, a)
+	sign := opts.Signer
+	opts.Signer = func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		signed, err := sign(address, withChainID(tx, id))
+		if err != nil {
+			return nil, fmt.Errorf("the signer at %s: %w", viper.GetString("signer"), err)
+		}
+		if err := checkSigned(signed, id); err != nil {
+			return nil, err
+		}
+		return signed, nil
+	}
+	opts.Context = ctx
+	return opts, nil
+}
+
+// withChainID sets the chain ID of a dynamic fee transaction, which the bindings
+// leave out. The external signer checks it against its own.
+func withChainID(tx *types.Transaction, id *big.Int) *types.Transaction {
+	if tx.Type() != types.DynamicFeeTxType {
+		return tx
+	}
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:    id,
+		Nonce:      tx.Nonce(),
+		GasTipCap:  tx.GasTipCap(),
+		GasFeeCap:  tx.GasFeeCap(),
+		Gas:        tx.Gas(),
+		To:         tx.To(),
+		Value:      tx.Value(),
+		Data:       tx.Data(),
+		AccessList: tx.AccessList(),
+	})
+}
+
+// unsigned is the signer of --dry-run and --unsigned-out, which leaves
+// transactions unsigned.
+func unsigned(_ common.Address, tx *types.Transaction) (*types.Transaction, error) {
+	return tx, nil
+}
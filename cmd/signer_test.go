@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"net"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	BKC "win/Code/BKC"
+	Simulated "win/Code/Simulated"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core"
+	"github.com/spf13/viper"
+)
+
+// standInSigner is a stand-in for clef, serving the account API with one key
+// and signing for its chain ID unless the request gives one.
+type standInSigner struct {
+	key     *ecdsa.PrivateKey
+	chainID *big.Int
+}
+
+type signTransactionResult struct {
+	Raw hexutil.Bytes      `json:"raw"`
+	Tx  *types.Transaction `json:"tx"`
+}
+
+func (s *standInSigner) Version() string {
+	return "6.1.0"
+}
+
+func (s *standInSigner) List() []common.Address {
+	return []common.Address{crypto.PubkeyToAddress(s.key.PublicKey)}
+}
+
+func (s *standInSigner) SignTransaction(args core.SendTxArgs, methodSelector *string) (*signTransactionResult, error) {
+	if args.From.Address() != crypto.PubkeyToAddress(s.key.PublicKey) {
+		return nil, errors.New("unknown account")
+	}
+	id := s.chainID
+	if args.ChainID != nil {
+		id = args.ChainID.ToInt()
+	}
+	var to *common.Address
+	if args.To != nil {
+		a := args.To.Address()
+		to = &a
+	}
+	var data []byte
+	if args.Data != nil {
+		data = *args.Data
+	}
+	var tx *types.Transaction
+	if args.MaxFeePerGas != nil {
+		tx = types.NewTx(&types.DynamicFeeTx{ChainID: id, Nonce: uint64(args.Nonce), GasTipCap: args.MaxPriorityFeePerGas.ToInt(), GasFeeCap: args.MaxFeePerGas.ToInt(),
+			Gas: uint64(args.Gas), To: to, Value: args.Value.ToInt(), Data: data})
+	} else {
+		tx = types.NewTx(&types.LegacyTx{Nonce: uint64(args.Nonce), GasPrice: args.GasPrice.ToInt(), Gas: uint64(args.Gas), To: to, Value: args.Value.ToInt(), Data: data})
+	}
+	signed, err := types.SignTx(tx, types.LatestSignerForChainID(id), s.key)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &signTransactionResult{Raw: raw, Tx: signed}, nil
+}
+
+// serveSigner serves the stand-in signer over http and ipc and returns the
+// --signer endpoints of both.
+func serveSigner(t *testing.T, s *standInSigner) map[string]string {
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("account", s); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(srv.Stop)
+
+	server := httptest.NewServer(srv)
+	t.Cleanup(server.Close)
+
+	path := filepath.Join(t.TempDir(), "clef.ipc")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go srv.ServeListener(l)
+
+	return map[string]string{"http": server.URL, "ipc": "ipc://" + path}
+}
+
+// useSigner sets --signer.
+func useSigner(t *testing.T, endpoint string) {
+	viper.Set("signer", endpoint)
+	t.Cleanup(func() { viper.Set("signer", "") })
+}
+
+func TestExternalSigner(t *testing.T) {
+	ctx := context.Background()
+	c := simulatedClient(t)
+	// the stand-in signs for a funded account of the simulated chain
+	account := simChain.Delegators[0]
+	endpoints := serveSigner(t, &standInSigner{key: account.Key, chainID: big.NewInt(Simulated.ChainID)})
+	id := big.NewInt(Simulated.ChainID)
+	to := simChain.Validators[2].Address
+
+	for name, endpoint := range endpoints {
+		t.Run(name, func(t *testing.T) {
+			useSigner(t, endpoint)
+			signAs(t, account.Address)
+			opts, err := transactOpts(ctx, c)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, tx := range []*types.Transaction{
+				types.NewTx(&types.LegacyTx{Nonce: 7, GasPrice: big.NewInt(1e9), Gas: 21000, To: &to, Value: big.NewInt(1)}),
+				// the bindings leave the chain ID of dynamic fee transactions out
+				types.NewTx(&types.DynamicFeeTx{Nonce: 7, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(1e9), Gas: 21000, To: &to, Value: big.NewInt(1)}),
+			} {
+				signed, err := opts.Signer(account.Address, tx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if signed.ChainId().Cmp(id) != 0 {
+					t.Errorf("type %d signed for chain ID %v, want %v", tx.Type(), signed.ChainId(), id)
+				}
+				if from, err := types.Sender(types.LatestSignerForChainID(id), signed); err != nil || from != account.Address {
+					t.Errorf("type %d signed by %s (%v), want %s", tx.Type(), from.Hex(), err, account.Address.Hex())
+				}
+			}
+
+			// and a transaction of the bindings is mined
+			receipt, err := send(ctx, c, opts, to, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+				opts.Value = oneEther
+				return c.StakePool.Delegate(opts, to)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				t.Error("the delegation signed by the signer failed")
+			}
+		})
+	}
+
+	t.Run("unlisted account", func(t *testing.T) {
+		useSigner(t, endpoints["http"])
+		other := simChain.Delegators[1].Address
+		if _, err := externalOpts(ctx, c, other); BKC.KindOf(err) != BKC.KindNotFound {
+			t.Errorf("externalOpts of an account the signer doesn't list = %v, want a not-found error", err)
+		}
+	})
+
+	t.Run("other chain", func(t *testing.T) {
+		endpoints := serveSigner(t, &standInSigner{key: account.Key, chainID: big.NewInt(5)})
+		useSigner(t, endpoints["ipc"])
+		opts, err := externalOpts(ctx, c, account.Address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx := types.NewTx(&types.LegacyTx{Nonce: 7, GasPrice: big.NewInt(1e9), Gas: 21000, To: &to, Value: big.NewInt(1)})
+		if _, err := opts.Signer(account.Address, tx); BKC.KindOf(err) != BKC.KindUsage {
+			t.Errorf("a signer on chain 5 signed for chain %v: %v", id, err)
+		}
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		useSigner(t, "ipc://"+filepath.Join(t.TempDir(), "none.ipc"))
+		if _, err := externalOpts(ctx, c, account.Address); BKC.KindOf(err) != BKC.KindConnection {
+			t.Errorf("externalOpts without a signer = %v, want a connection error", err)
+		}
+	})
+}
@@ -16,14 +16,12 @@ limitations under the License.
 package cmd
 
 import (
-	"fmt"
-	"strconv"
+	"context"
+	"math/big"
 	"time"
-	Init "win/Code/Init"
-	"win/abi/stakepool"
-	ETHclient "win/client"
+	BKC "win/Code/BKC"
+	Output "win/Code/Output"
 
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/spf13/cobra"
 )
@@ -31,150 +29,279 @@ import (
 // stakepoolCmd represents the stakepool command
 var stakepoolCmd = &cobra.Command{
 	Use:   "stakepool",
-	Short: "query the stake pool contract",
+	Short: "query and delegate with the stake pool contract",
 	Long:  `This contract consists of staking module and delegation logics`,
+	Args:  cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		client, err := ETHclient.Client()
+		flag, err := legacyFlag(cmd, "ExcludeUnbonding")
 		handleError(err)
+		if exclude && flag != "totalDelegation" {
+			handleError(BKC.Errorf(BKC.KindUsage, "--ExcludeUnbonding only applies to --totalDelegation"))
+		}
+		if flag == "" {
+			cmd.Help()
+			return
+		}
 
-		name := "stake pool"
+		c, err := newClient()
+		handleError(err)
 
-		//StakePoolAddress := "0xD825E46b1f610Aa96e6A9454aD99B1CA321B2751"
+		switch flag {
+		case "alreadyInit":
+			render(GetInitStatus(cmd.Context(), c, BKC.StakePoolContract))
+		case "ValidatorDelegation":
+			addrs, err := addressPair(cmd, flag)
+			handleError(err)
+			render(GetDelegationAmountOfEach(cmd.Context(), c, addrs))
+		case "totalDelegation":
+			addr, err := addressFlag(cmd, flag)
+			handleError(err)
+			if !exclude {
+				render(GetTotalDelegation(cmd.Context(), c, addr))
+			} else {
+				render(GetTotalDelegationExcludeUnbonding(cmd.Context(), c, addr))
+			}
+		case "Delegators":
+			addr, err := addressFlag(cmd, flag)
+			handleError(err)
+			render(GetDelegators(cmd.Context(), c, addr))
+		case "UserDelegationBonded":
+			addrs, err := addressPair(cmd, flag)
+			handleError(err)
+			render(GetUserDelegationBondedAmount(cmd.Context(), c, addrs))
+		case "UserDelegationUnbonding":
+			addrs, err := addressPair(cmd, flag)
+			handleError(err)
+			render(GetUserDelegationUnbondingAmount(cmd.Context(), c, addrs))
+		case "getUnbondQueueValue":
+			addr, err := addressFlag(cmd, flag)
+			handleError(err)
+			render(GetUserUnDelegateValue(cmd.Context(), c, addr))
+		}
+	},
+}
 
-		StakePoolInstance, err := stakepool.NewStakepool(common.HexToAddress(StakePoolAddress), client)
+var stakepoolInitStatusCmd = &cobra.Command{
+	Use:   "init-status",
+	Short: "whether the stake pool contract is initialised",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
 		handleError(err)
+		render(GetInitStatus(cmd.Context(), c, BKC.StakePoolContract))
+	},
+}
 
-		val, err := cmd.Flags().GetBool("alreadyInit")
-		handleError(err)
-		addrs, err := cmd.Flags().GetStringSlice("ValidatorDelegation")
-		handleError(err)
-		addr, err := cmd.Flags().GetString("totalDelegation")
-		handleError(err)
-		addr2, err := cmd.Flags().GetString("Delegators")
+var stakepoolDelegationCmd = &cobra.Command{
+	Use:   "delegation <validator> <delegator>",
+	Short: "the amount a delegator delegates to a validator",
+	Args:  addressArgs("validator", "delegator"),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
 		handleError(err)
-		addrs2, err := cmd.Flags().GetStringSlice("UserDelegationBonded")
+		render(GetDelegationAmountOfEach(cmd.Context(), c, args))
+	},
+}
+
+var stakepoolBondedCmd = &cobra.Command{
+	Use:   "bonded <validator> <delegator>",
+	Short: "the bonded amount a delegator delegates to a validator",
+	Args:  addressArgs("validator", "delegator"),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
 		handleError(err)
-		addrs3, err := cmd.Flags().GetStringSlice("UserDelegationUnbonding")
+		render(GetUserDelegationBondedAmount(cmd.Context(), c, args))
+	},
+}
+
+var stakepoolUnbondingCmd = &cobra.Command{
+	Use:   "unbonding <validator> <delegator>",
+	Short: "the amount a delegator is unbonding from a validator",
+	Args:  addressArgs("validator", "delegator"),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
 		handleError(err)
-		e, err := cmd.Flags().GetBool("ExcludeUnbonding")
+		render(GetUserDelegationUnbondingAmount(cmd.Context(), c, args))
+	},
+}
+
+var stakepoolTotalDelegationCmd = &cobra.Command{
+	Use:   "total-delegation <validator>",
+	Short: "the total delegation of a validator",
+	Args:  addressArgs("validator"),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
 		handleError(err)
-		deleg, err := cmd.Flags().GetString("getUnbondQueueValue")
+		e, err := cmd.Flags().GetBool("exclude-unbonding")
 		handleError(err)
-		if val {
-			Init.AlreadyInit(*client, StakePoolInstance, name)
-		} else if addrs[0] != "" && addrs[1] != "" {
-			GetDelegationAmountOfEach(StakePoolInstance, addrs)
-		} else if addr != "" {
-			if !e {
-				GetTotalDelegation(StakePoolInstance, addr)
-			} else {
-				GetTotalDelegationExcludeUnbonding(StakePoolInstance, addr)
-			}
-		} else if addr2 != "" {
-			GetDelegators(StakePoolInstance, addr2)
-		} else if addrs2[0] != "" && addrs2[1] != "" {
-			GetUserDelegationBondedAmount(StakePoolInstance, addrs2)
-		} else if addrs3[0] != "" && addrs3[1] != "" {
-			GetUserDelegationUnbondingAmount(StakePoolInstance, addrs3)
-		} else if deleg != "" {
-			GetUserUnDelegateValue(StakePoolInstance, deleg)
+		if e {
+			render(GetTotalDelegationExcludeUnbonding(cmd.Context(), c, args[0]))
 		} else {
-			fmt.Println("please specify the field you want to query")
+			render(GetTotalDelegation(cmd.Context(), c, args[0]))
 		}
 	},
 }
 
+var stakepoolDelegatorsCmd = &cobra.Command{
+	Use:   "delegators <validator>",
+	Short: "the list of delegators of a validator",
+	Args:  addressArgs("validator"),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
+		handleError(err)
+		render(GetDelegators(cmd.Context(), c, args[0]))
+	},
+}
+
+var stakepoolUnbondingQueueCmd = &cobra.Command{
+	Use:   "unbonding-queue <delegator>",
+	Short: "the undelegations of a delegator waiting in the unbonding queue",
+	Args:  addressArgs("delegator"),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
+		handleError(err)
+		render(GetUserUnDelegateValue(cmd.Context(), c, args[0]))
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(stakepoolCmd)
+	stakepoolCmd.AddCommand(stakepoolInitStatusCmd)
+	stakepoolCmd.AddCommand(stakepoolDelegationCmd)
+	stakepoolCmd.AddCommand(stakepoolBondedCmd)
+	stakepoolCmd.AddCommand(stakepoolUnbondingCmd)
+	stakepoolCmd.AddCommand(stakepoolTotalDelegationCmd)
+	stakepoolTotalDelegationCmd.Flags().BoolP("exclude-unbonding", "e", false, "exclude the amounts being unbonded")
+	stakepoolCmd.AddCommand(stakepoolDelegatorsCmd)
+	stakepoolCmd.AddCommand(stakepoolUnbondingQueueCmd)
+
+	// the flags below are the original query interface, kept as deprecated aliases of the subcommands
 	stakepoolCmd.Flags().BoolVarP(&alreadyInit, "alreadyInit", "i", false, "the init status")
+	stakepoolCmd.Flags().MarkDeprecated("alreadyInit", "use `stakepool init-status` instead")
 
-	stakepoolCmd.Flags().StringSliceP("ValidatorDelegation", "v", []string{"", ""}, "The delegate amount of each of delegator for a validator (specify validator address then delegator address separated by a comma)")
+	stakepoolCmd.Flags().StringSliceP("ValidatorDelegation", "v", nil, "The delegate amount of each of delegator for a validator (specify validator address then delegator address separated by a comma)")
+	stakepoolCmd.Flags().MarkDeprecated("ValidatorDelegation", "use `stakepool delegation <validator> <delegator>` instead")
 
 	stakepoolCmd.Flags().StringVarP(&consensusAddress, "totalDelegation", "t", "", "the total delegation of a validator (specify address)")
+	stakepoolCmd.Flags().MarkDeprecated("totalDelegation", "use `stakepool total-delegation <validator>` instead")
 
 	stakepoolCmd.Flags().StringVarP(&consensusAddress2, "Delegators", "d", "", "the list of delegators of this validator (specify address)")
+	stakepoolCmd.Flags().MarkDeprecated("Delegators", "use `stakepool delegators <validator>` instead")
 
 	stakepoolCmd.Flags().BoolVarP(&exclude, "ExcludeUnbonding", "e", false, "the flag to add to totalDelegation flag to get total delegation exclude unbonding amounts")
+	stakepoolCmd.Flags().MarkDeprecated("ExcludeUnbonding", "use `stakepool total-delegation --exclude-unbonding <validator>` instead")
 
-	stakepoolCmd.Flags().StringSliceP("UserDelegationBonded", "b", []string{"", ""}, "The bonded amount of delegator for the validator (specify validator address then delegator address separated by a comma)")
+	stakepoolCmd.Flags().StringSliceP("UserDelegationBonded", "b", nil, "The bonded amount of delegator for the validator (specify validator address then delegator address separated by a comma)")
+	stakepoolCmd.Flags().MarkDeprecated("UserDelegationBonded", "use `stakepool bonded <validator> <delegator>` instead")
 
-	stakepoolCmd.Flags().StringSliceP("UserDelegationUnbonding", "u", []string{"", ""}, "The unbonding amount of delegator for validator (specify validator address then delegator address separated by a comma)")
+	stakepoolCmd.Flags().StringSliceP("UserDelegationUnbonding", "u", nil, "The unbonding amount of delegator for validator (specify validator address then delegator address separated by a comma)")
+	stakepoolCmd.Flags().MarkDeprecated("UserDelegationUnbonding", "use `stakepool unbonding <validator> <delegator>` instead")
 
 	stakepoolCmd.Flags().StringVarP(&delegatorAddress, "getUnbondQueueValue", "q", "", "The unbonding value in the queue of this delegator (specify the delegator address)")
-	// Here you will define your flags and configuration settings.
+	stakepoolCmd.Flags().MarkDeprecated("getUnbondQueueValue", "use `stakepool unbonding-queue <delegator>` instead")
+}
+
+// Delegation is an amount delegated by a delegator to a validator. Kind tells
+// whether it is the whole delegation, its bonded part or its unbonding part.
+type Delegation struct {
+	Validator   string `json:"validator" yaml:"validator"`
+	Delegator   string `json:"delegator" yaml:"delegator"`
+	Kind        string `json:"kind" yaml:"kind"`
+	AmountWei   string `json:"amount_wei" yaml:"amount_wei"`
+	AmountEther string `json:"amount_ether" yaml:"amount_ether"`
+}
+
+// TotalDelegation is the sum of the delegations to a validator.
+type TotalDelegation struct {
+	Validator        string `json:"validator" yaml:"validator"`
+	ExcludeUnbonding bool   `json:"exclude_unbonding" yaml:"exclude_unbonding"`
+	AmountWei        string `json:"amount_wei" yaml:"amount_wei"`
+	AmountEther      string `json:"amount_ether" yaml:"amount_ether"`
+}
 
-	// Cobra supports Persistent Flags which will work for this command
+// Delegator is an entry of the delegators list of a validator.
+type Delegator struct {
+	Index     int    `json:"index" yaml:"index"`
+	Delegator string `json:"delegator" yaml:"delegator"`
+}
 
-	// and all subcommands, e.g.:
-	// stakepoolCmd.PersistentFlags().String("foo", "", "A help for foo")
+// Undelegation is an entry of the unbonding queue of a delegator.
+type Undelegation struct {
+	Index       int    `json:"index" yaml:"index"`
+	Validator   string `json:"validator" yaml:"validator"`
+	AmountWei   string `json:"amount_wei" yaml:"amount_wei"`
+	AmountEther string `json:"amount_ether" yaml:"amount_ether"`
+	Time        string `json:"time" yaml:"time"`
+	Unix        int64  `json:"unix" yaml:"unix"`
+}
 
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// stakepoolCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+func newDelegation(validator string, delegator string, kind string, amount *big.Int) Delegation {
+	return Delegation{
+		Validator:   common.HexToAddress(validator).Hex(),
+		Delegator:   common.HexToAddress(delegator).Hex(),
+		Kind:        kind,
+		AmountWei:   Output.Wei(amount),
+		AmountEther: Output.Ether(amount),
+	}
 }
 
-func GetDelegationAmountOfEach(instance *stakepool.Stakepool, addr []string) {
-	deleg, err := instance.GetDelegationAmountOfEach(&bind.CallOpts{}, common.HexToAddress(addr[0]), common.HexToAddress(addr[1]))
+func GetDelegationAmountOfEach(ctx context.Context, c *BKC.Client, addr []string) Delegation {
+	deleg, err := c.Delegation(ctx, common.HexToAddress(addr[0]), common.HexToAddress(addr[1]))
 	handleError(err)
-	fmt.Println()
-	fmt.Println("The total delegation of delegator", addr[1], "for validator", addr[0], "is", deleg)
-	fmt.Println()
+	return newDelegation(addr[0], addr[1], "total", deleg)
 }
 
-func GetTotalDelegation(instance *stakepool.Stakepool, addr string) {
-	tt, err := instance.GetTotalDelegation(&bind.CallOpts{}, common.HexToAddress(addr))
+func GetTotalDelegation(ctx context.Context, c *BKC.Client, addr string) TotalDelegation {
+	tt, err := c.TotalDelegation(ctx, common.HexToAddress(addr), false)
 	handleError(err)
-	fmt.Println()
-	fmt.Println("The total delegation of the validator", addr, "is", tt)
-	fmt.Println()
+	return TotalDelegation{Validator: common.HexToAddress(addr).Hex(), AmountWei: Output.Wei(tt), AmountEther: Output.Ether(tt)}
 }
 
-func GetTotalDelegationExcludeUnbonding(instance *stakepool.Stakepool, addr string) {
-	totalExclude, err := instance.GetTotalDelegationExcludeUnbonding(&bind.CallOpts{}, common.HexToAddress(addr))
+func GetTotalDelegationExcludeUnbonding(ctx context.Context, c *BKC.Client, addr string) TotalDelegation {
+	totalExclude, err := c.TotalDelegation(ctx, common.HexToAddress(addr), true)
 	handleError(err)
-	fmt.Println()
-	fmt.Println("The total delegation exclude unbonding amount of the validator", addr, "is", totalExclude)
-	fmt.Println()
+	return TotalDelegation{Validator: common.HexToAddress(addr).Hex(), ExcludeUnbonding: true, AmountWei: Output.Wei(totalExclude), AmountEther: Output.Ether(totalExclude)}
 }
 
-func GetUserDelegationBondedAmount(instance *stakepool.Stakepool, addrs2 []string) {
-	b, err := instance.GetUserDelegationBondedAmountCallable(&bind.CallOpts{}, common.HexToAddress(addrs2[1]), common.HexToAddress(addrs2[0]))
+func GetUserDelegationBondedAmount(ctx context.Context, c *BKC.Client, addrs2 []string) Delegation {
+	b, err := c.BondedDelegation(ctx, common.HexToAddress(addrs2[0]), common.HexToAddress(addrs2[1]))
 	handleError(err)
-	fmt.Println()
-	fmt.Println("The bonded amount of delegator", addrs2[1], "for validator", addrs2[0], "is", b)
-	fmt.Println()
+	return newDelegation(addrs2[0], addrs2[1], "bonded", b)
 }
 
-func GetUserDelegationUnbondingAmount(instance *stakepool.Stakepool, addrs3 []string) {
-	u, err := instance.GetUserDelegationUnbondingAmountCallable(&bind.CallOpts{}, common.HexToAddress(addrs3[1]), common.HexToAddress(addrs3[0]))
+func GetUserDelegationUnbondingAmount(ctx context.Context, c *BKC.Client, addrs3 []string) Delegation {
+	u, err := c.UnbondingDelegation(ctx, common.HexToAddress(addrs3[0]), common.HexToAddress(addrs3[1]))
 	handleError(err)
-	fmt.Println()
-	fmt.Println("The unbonding amount of delegator", addrs3[1], "for validator", addrs3[0], "is", u)
-	fmt.Println()
+	return newDelegation(addrs3[0], addrs3[1], "unbonding", u)
 }
 
-func GetUserUnDelegateValue(instance *stakepool.Stakepool, deleg string) {
-	q, err := instance.GetUnbondingValue(&bind.CallOpts{}, common.HexToAddress(deleg))
+func GetUserUnDelegateValue(ctx context.Context, c *BKC.Client, deleg string) []Undelegation {
+	q, err := c.UnbondingQueue(ctx, common.HexToAddress(deleg))
 	handleError(err)
-	fmt.Println()
-	fmt.Println("for delegator", deleg)
-	for i, qval := range q {
-		fmt.Println()
-		fmt.Println("undelegate no." + strconv.Itoa(i+1))
-		fmt.Println("Amount: ", qval.Amount)
-		fmt.Println("Time: ", time.Unix(int64(qval.Time.Uint64()), 0))
-		fmt.Println("Validator: ", qval.Validator)
+	return newUndelegations(q)
+}
+
+func newUndelegations(q []BKC.Undelegation) []Undelegation {
+	undelegations := []Undelegation{}
+	for _, qval := range q {
+		undelegations = append(undelegations, Undelegation{
+			Index:       qval.Index,
+			Validator:   qval.Validator.Hex(),
+			AmountWei:   Output.Wei(qval.Amount),
+			AmountEther: Output.Ether(qval.Amount),
+			Time:        qval.Time.Format(time.RFC3339),
+			Unix:        qval.Time.Unix(),
+		})
 	}
-	fmt.Println()
+	return undelegations
 }
 
-func GetDelegators(instance *stakepool.Stakepool, addr2 string) {
-	delegators, err := instance.GetDelegators(&bind.CallOpts{}, common.HexToAddress(addr2))
+func GetDelegators(ctx context.Context, c *BKC.Client, addr2 string) []Delegator {
+	delegators, err := c.Delegators(ctx, common.HexToAddress(addr2))
 	handleError(err)
-	fmt.Println()
-	fmt.Println("for validator:", addr2)
+	result := []Delegator{}
 	for i, d := range delegators {
-		fmt.Println("delegator", i, ":", d)
+		result = append(result, Delegator{Index: i, Delegator: d.Hex()})
 	}
-	fmt.Println()
+	return result
 }
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+	BKC "win/Code/BKC"
+	Output "win/Code/Output"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/cobra"
+)
+
+// The write commands of the stake pool. They sign with --from, which is the
+// delegator they act on.
+
+var stakepoolDelegateCmd = &cobra.Command{
+	Use:   "delegate <validator> --amount <ether>",
+	Short: "delegate to a validator from the --from account",
+	Long: `Delegates the amount sent along to a validator of the pool. The stake pool only
+accepts delegations while the validator is unbonded, which is checked before
+sending.`,
+	Args: addressArgs("validator"),
+	Run: func(cmd *cobra.Command, args []string) {
+		amount, err := etherFlag(cmd, "amount")
+		handleError(err)
+		c, err := writeClient()
+		handleError(err)
+		opts, err := transactOpts(cmd.Context(), c)
+		handleError(err)
+		validator := common.HexToAddress(args[0])
+
+		handleError(c.Explain(cmd.Context(), c.CheckDelegate(cmd.Context(), validator, amount), validator))
+		build := func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			opts.Value = amount
+			return c.StakePool.Delegate(opts, validator)
+		}
+		transact(cmd.Context(), c, opts, validator, build)
+	},
+}
+
+var stakepoolUndelegateCmd = &cobra.Command{
+	Use:   "undelegate <validator> --amount <ether>",
+	Short: "take back part of the delegation of the --from account",
+	Long: `Undelegates the amount from a validator. While the validator is bonded or
+unbonding the amount goes through the unbonding queue and is paid out by
+` + "`stakepool claim`" + ` once the unbonding period is over. Otherwise it is paid out
+at once.`,
+	Args: addressArgs("validator"),
+	Run: func(cmd *cobra.Command, args []string) {
+		amount, err := etherFlag(cmd, "amount")
+		handleError(err)
+		c, err := writeClient()
+		handleError(err)
+		opts, err := transactOpts(cmd.Context(), c)
+		handleError(err)
+		validator := common.HexToAddress(args[0])
+
+		handleError(c.CheckUndelegate(cmd.Context(), validator, opts.From, amount))
+		build := func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return c.StakePool.Undelegate(opts, validator, amount)
+		}
+		transact(cmd.Context(), c, opts, validator, build)
+	},
+}
+
+var stakepoolClaimCmd = &cobra.Command{
+	Use:   "claim",
+	Short: "pay out the matured undelegations of the --from account",
+	Long: `Calls removeUnbondingUserFromUnbondingQueue, which pays out the entries of the
+unbonding queue of the --from account whose unbonding period is over. The
+matured entries and their total are listed before sending.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		c, err := writeClient()
+		handleError(err)
+		opts, err := transactOpts(ctx, c)
+		handleError(err)
+
+		mature, total, next, err := c.MatureUndelegations(ctx, opts.From)
+		handleError(err)
+		if len(mature) == 0 {
+			if next.IsZero() {
+				handleError(BKC.Errorf(BKC.KindNotFound, "%s has nothing in the unbonding queue", opts.From.Hex()))
+			}
+			handleError(BKC.Errorf(BKC.KindNotFound, "no undelegation of %s has matured yet, the next one matures at %s", opts.From.Hex(), next.Format(time.RFC3339)))
+		}
+		result := ClaimResult{
+			Delegator:  opts.From.Hex(),
+			Entries:    newUndelegations(mature),
+			TotalWei:   Output.Wei(total),
+			TotalEther: Output.Ether(total),
+		}
+		fmt.Fprintf(os.Stderr, "Claiming %d matured undelegations, %s ether in total\n", len(mature), result.TotalEther)
+		for _, u := range result.Entries {
+			fmt.Fprintf(os.Stderr, "  #%d %s ether from %s, matured at %s\n", u.Index, u.AmountEther, u.Validator, u.Time)
+		}
+
+		receipt, err := previewOrSend(ctx, c, opts, opts.From, c.StakePool.RemoveUnbondingUserFromUnbondingQueue)
+		handleError(err)
+		if receipt == nil {
+			return
+		}
+		result.Tx = newTxResult(receipt, opts.From)
+		render(result)
+	},
+}
+
+// ClaimResult is the payout of the matured undelegations of a delegator.
+type ClaimResult struct {
+	Delegator  string         `json:"delegator" yaml:"delegator"`
+	Entries    []Undelegation `json:"entries" yaml:"entries"`
+	TotalWei   string         `json:"total_wei" yaml:"total_wei"`
+	TotalEther string         `json:"total_ether" yaml:"total_ether"`
+	Tx         TxResult       `json:"tx" yaml:"tx"`
+}
+
+func init() {
+	stakepoolCmd.AddCommand(stakepoolDelegateCmd)
+	stakepoolCmd.AddCommand(stakepoolUndelegateCmd)
+	stakepoolCmd.AddCommand(stakepoolClaimCmd)
+	addWriteFlags(stakepoolDelegateCmd, stakepoolUndelegateCmd, stakepoolClaimCmd)
+
+	stakepoolDelegateCmd.Flags().String("amount", "", "the amount to delegate in ether")
+	stakepoolUndelegateCmd.Flags().String("amount", "", "the amount to undelegate in ether")
+}
@@ -16,12 +16,10 @@ limitations under the License.
 package cmd
 
 import (
-	"fmt"
-	Init "win/Code/Init"
-	"win/abi/systemreward"
-	ETHclient "win/client"
+	"context"
+	BKC "win/Code/BKC"
+	Output "win/Code/Output"
 
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/spf13/cobra"
 )
@@ -29,70 +27,104 @@ import (
 // systemrewardCmd represents the systemreward command
 var systemrewardCmd = &cobra.Command{
 	Use:   "systemreward",
-	Short: "query the system reward contract",
+	Short: "query and fund the system reward contract",
 	Long:  `This contract consists of reward distributing logics`,
+	Args:  cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		client, err := ETHclient.Client()
+		flag, err := legacyFlag(cmd)
 		handleError(err)
+		if flag == "" {
+			cmd.Help()
+			return
+		}
 
-		name := "system reward"
+		c, err := newClient()
+		handleError(err)
 
-		//SystemRewardAddress := "0xd5267A4551754F858EFe111073b0fB96d79409b7"
+		switch flag {
+		case "alreadyInit":
+			render(GetInitStatus(cmd.Context(), c, BKC.SystemRewardContract))
+		case "rewardMapping":
+			addr, err := addressFlag(cmd, flag)
+			handleError(err)
+			render(GetRewardMapping(cmd.Context(), c, addr))
+		case "getBalance":
+			render(GetBalance(cmd.Context(), c))
+		}
+	},
+}
 
-		SystemRewardInstance, err := systemreward.NewSystemreward(common.HexToAddress(SystemRewardAddress), client)
+var systemrewardInitStatusCmd = &cobra.Command{
+	Use:   "init-status",
+	Short: "whether the system reward contract is initialised",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
 		handleError(err)
+		render(GetInitStatus(cmd.Context(), c, BKC.SystemRewardContract))
+	},
+}
 
-		val, err := cmd.Flags().GetBool("alreadyInit")
-		handleError(err)
-		addr, err := cmd.Flags().GetString("rewardMapping")
-		handleError(err)
-		b, err := cmd.Flags().GetBool("getBalance")
+var systemrewardRewardCmd = &cobra.Command{
+	Use:   "reward <validator>",
+	Short: "the reward waiting to be distributed for a validator",
+	Args:  addressArgs("validator"),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
 		handleError(err)
+		render(GetRewardMapping(cmd.Context(), c, args[0]))
+	},
+}
 
-		if val {
-			Init.AlreadyInit(*client, SystemRewardInstance, name)
-		} else if addr != "" {
-			GetRewardMapping(SystemRewardInstance, addr)
-		} else if b {
-			GetBalance(SystemRewardInstance)
-		} else {
-			fmt.Println("please specify the field you want to query")
-		}
+var systemrewardBalanceCmd = &cobra.Command{
+	Use:   "balance",
+	Short: "the balance of the system reward contract",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
+		handleError(err)
+		render(GetBalance(cmd.Context(), c))
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(systemrewardCmd)
+	systemrewardCmd.AddCommand(systemrewardInitStatusCmd)
+	systemrewardCmd.AddCommand(systemrewardRewardCmd)
+	systemrewardCmd.AddCommand(systemrewardBalanceCmd)
+
+	// the flags below are the original query interface, kept as deprecated aliases of the subcommands
 	systemrewardCmd.Flags().BoolVarP(&alreadyInit, "alreadyInit", "i", false, "the init status")
+	systemrewardCmd.Flags().MarkDeprecated("alreadyInit", "use `systemreward init-status` instead")
 
 	systemrewardCmd.Flags().StringVarP(&consensusAddress, "rewardMapping", "r", "", "the reward of the validator (specify address)")
+	systemrewardCmd.Flags().MarkDeprecated("rewardMapping", "use `systemreward reward <validator>` instead")
 
 	systemrewardCmd.Flags().BoolVarP(&balance, "getBalance", "b", false, "the balance of the system reward contract")
+	systemrewardCmd.Flags().MarkDeprecated("getBalance", "use `systemreward balance` instead")
+}
 
-	// Here you will define your flags and configuration settings.
-
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// systemrewardCmd.PersistentFlags().String("foo", "", "A help for foo")
+// Reward is the reward accumulated for a validator in rewardMapping.
+type Reward struct {
+	Validator   string `json:"validator" yaml:"validator"`
+	RewardWei   string `json:"reward_wei" yaml:"reward_wei"`
+	RewardEther string `json:"reward_ether" yaml:"reward_ether"`
+}
 
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// systemrewardCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+// Balance is the balance of the system reward contract.
+type Balance struct {
+	BalanceWei   string `json:"balance_wei" yaml:"balance_wei"`
+	BalanceEther string `json:"balance_ether" yaml:"balance_ether"`
 }
 
-func GetRewardMapping(instance *systemreward.Systemreward, addr string) {
-	reward, err := instance.RewardMapping(&bind.CallOpts{}, common.HexToAddress(addr))
+func GetRewardMapping(ctx context.Context, c *BKC.Client, addr string) Reward {
+	reward, err := c.Reward(ctx, common.HexToAddress(addr))
 	handleError(err)
-	fmt.Println()
-	fmt.Println("The reward for validator address", addr, "is", reward)
-	fmt.Println()
+	return Reward{Validator: common.HexToAddress(addr).Hex(), RewardWei: Output.Wei(reward), RewardEther: Output.Ether(reward)}
 }
 
-func GetBalance(instance *systemreward.Systemreward) {
-	bal, err := instance.GetBalance(&bind.CallOpts{})
+func GetBalance(ctx context.Context, c *BKC.Client) Balance {
+	bal, err := c.RewardBalance(ctx)
 	handleError(err)
-
-	fmt.Println()
-	fmt.Println("The balance of the system reward contract is", bal)
-	fmt.Println()
+	return Balance{BalanceWei: Output.Wei(bal), BalanceEther: Output.Ether(bal)}
 }
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	BKC "win/Code/BKC"
+	Output "win/Code/Output"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/cobra"
+)
+
+var systemrewardAddRewardCmd = &cobra.Command{
+	Use:   "add-reward <validator> --amount <ether>",
+	Short: "add to the reward of a validator of the active set",
+	Long: `Calls addReward with the amount sent along. 90% of it is credited to the reward
+of the validator, which is distributed to it and its delegators when the active
+set is updated. The rest stays in the contract for maintenance.`,
+	Args: addressArgs("validator"),
+	Run: func(cmd *cobra.Command, args []string) {
+		amount, err := etherFlag(cmd, "amount")
+		handleError(err)
+		c, err := writeClient()
+		handleError(err)
+		opts, err := transactOpts(cmd.Context(), c)
+		handleError(err)
+		validator := common.HexToAddress(args[0])
+
+		credited, err := c.CheckAddReward(cmd.Context(), validator, amount)
+		handleError(err)
+		fmt.Fprintf(os.Stderr, "%s ether of the %s ether sent will be credited to the reward of %s\n",
+			Output.Ether(credited), Output.Ether(amount), validator.Hex())
+		build := func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			opts.Value = amount
+			return c.SystemReward.AddReward(opts, validator)
+		}
+		transact(cmd.Context(), c, opts, validator, build)
+	},
+}
+
+var systemrewardFundCmd = &cobra.Command{
+	Use:   "fund --amount <ether>",
+	Short: "send funds to the system reward contract",
+	Long: `Calls fund with the amount sent along. It adds to the balance the rewards are
+paid from, without crediting the reward of any validator.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		amount, err := etherFlag(cmd, "amount")
+		handleError(err)
+		if amount.Sign() <= 0 {
+			handleError(BKC.Errorf(BKC.KindUsage, "the amount must be greater than 0"))
+		}
+		c, err := writeClient()
+		handleError(err)
+		opts, err := transactOpts(cmd.Context(), c)
+		handleError(err)
+
+		build := func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			opts.Value = amount
+			return c.SystemReward.Fund(opts)
+		}
+		transact(cmd.Context(), c, opts, opts.From, build)
+	},
+}
+
+func init() {
+	systemrewardCmd.AddCommand(systemrewardAddRewardCmd)
+	systemrewardCmd.AddCommand(systemrewardFundCmd)
+	addWriteFlags(systemrewardAddRewardCmd, systemrewardFundCmd)
+
+	systemrewardAddRewardCmd.Flags().String("amount", "", "the amount to send in ether")
+	systemrewardFundCmd.Flags().String("amount", "", "the amount to send in ether")
+}
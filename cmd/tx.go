@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	BKC "win/Code/BKC"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/spf13/cobra"
+)
+
+// txCmd represents the tx command
+var txCmd = &cobra.Command{
+	Use:   "tx",
+	Short: "audit and replace the transactions sent by the cli",
+	Long: `Every transaction sent by the write commands is recorded in the journal,
+journal.jsonl under --datadir, with the command that sent it, its signer, its
+nonce and its outcome. Transactions of --simulated runs aren't recorded.
+
+A transaction that is stuck, and holds up those sent after it by the same
+signer, is replaced with tx speedup or tx cancel. A transaction written by a
+write command with --unsigned-out is signed with tx sign, which needs no node,
+and sent with tx broadcast.`,
+}
+
+var txListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the transactions of the journal, oldest first",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		limit, err := cmd.Flags().GetInt("limit")
+		handleError(err)
+		entries, err := readJournal()
+		handleError(err)
+		if limit > 0 && len(entries) > limit {
+			entries = entries[len(entries)-limit:]
+		}
+		views := []JournalLine{}
+		for _, e := range entries {
+			views = append(views, JournalLine{
+				Hash:    e.Hash,
+				Time:    e.Time,
+				Network: e.Network,
+				Command: e.Command,
+				Signer:  e.Signer,
+				Nonce:   e.Nonce,
+				Outcome: e.Outcome,
+			})
+		}
+		render(views)
+	},
+}
+
+var txShowCmd = &cobra.Command{
+	Use:   "show <hash>",
+	Short: "show a transaction of the journal",
+	Long: `Shows a transaction of the journal. When it is still pending its receipt is
+looked up on the node of its network and the outcome is recorded, unless the
+network now points at another chain than the one it was sent on.`,
+	Args: hashArg,
+	Run: func(cmd *cobra.Command, args []string) {
+		entry, err := findEntry(args[0])
+		handleError(err)
+		if entry.Outcome == "pending" {
+			entry = refreshPending(cmd, entry)
+		}
+		render(entry)
+	},
+}
+
+// findEntry is the journal entry of the transaction hash.
+func findEntry(hash string) (JournalEntry, error) {
+	hash = common.HexToHash(hash).Hex()
+	entries, err := readJournal()
+	if err != nil {
+		return JournalEntry{}, err
+	}
+	for _, entry := range entries {
+		if entry.Hash == hash {
+			return entry, nil
+		}
+	}
+	return JournalEntry{}, BKC.Errorf(BKC.KindNotFound, "transaction %s is not in the journal", hash)
+}
+
+// JournalLine is a line of tx list.
+type JournalLine struct {
+	Hash    string `json:"hash" yaml:"hash"`
+	Time    string `json:"time" yaml:"time"`
+	Network string `json:"network" yaml:"network"`
+	Command string `json:"command" yaml:"command"`
+	Signer  string `json:"signer" yaml:"signer"`
+	Nonce   uint64 `json:"nonce" yaml:"nonce"`
+	Outcome string `json:"outcome" yaml:"outcome"`
+}
+
+// refreshPending looks up the receipt of a pending entry on the node of its
+// network. When the node can't tell, the entry is shown as the journal has it.
+func refreshPending(cmd *cobra.Command, entry JournalEntry) JournalEntry {
+	n, err := loadNetwork(entry.Network)
+	if err == nil {
+		var c *BKC.Client
+		if c, err = dialNetwork(entry.Network, n); err == nil {
+			var refreshed JournalEntry
+			if refreshed, err = refreshEntry(cmd.Context(), c, entry); err == nil {
+				return refreshed
+			}
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Warning: can't look up the receipt of %s: %v\n", entry.Hash, err)
+	return entry
+}
+
+// hashArg checks that the only argument is a transaction hash.
+func hashArg(cmd *cobra.Command, args []string) error {
+	if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+		return err
+	}
+	if b, err := hexutil.Decode(args[0]); err != nil || len(b) != common.HashLength {
+		return BKC.Errorf(BKC.KindUsage, "invalid transaction hash %q", args[0])
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(txCmd)
+	txCmd.AddCommand(txListCmd)
+	txCmd.AddCommand(txShowCmd)
+	txListCmd.Flags().Int("limit", 0, "only list the last transactions, 0 for all")
+}
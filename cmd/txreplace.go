@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+	BKC "win/Code/BKC"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// bump is --bump of tx speedup and tx cancel.
+var bump uint64
+
+var txSpeedupCmd = &cobra.Command{
+	Use:   "speedup <hash>",
+	Short: "send a pending transaction of the journal again with a higher gas price",
+	Long: `Sends a transaction of the journal that isn't mined yet again, with the same
+nonce, recipient, value and data, and a gas price raised by --bump percent, or
+the one the node suggests when it is higher. Nodes only take a replacement whose
+gas price, or tip and fee cap, are at least 10% higher.
+
+Whichever of the two transactions is mined is waited for, and the other one is
+recorded as replaced in the journal. The transactions queued behind it, with the
+next nonces of the signer, can be mined once it is.`,
+	Args: hashArg,
+	Run: func(cmd *cobra.Command, args []string) {
+		replace(cmd, args[0], false)
+	},
+}
+
+var txCancelCmd = &cobra.Command{
+	Use:   "cancel <hash>",
+	Short: "replace a pending transaction of the journal with a transfer of nothing",
+	Long: `Replaces a transaction of the journal that isn't mined yet with a transfer of
+no value from its signer to itself, with the same nonce and a gas price raised as
+by tx speedup. Once the transfer is mined the original transaction can't be
+anymore. A transaction the node has dropped can be cancelled too, which frees
+its nonce for the transactions queued behind it.`,
+	Args: hashArg,
+	Run: func(cmd *cobra.Command, args []string) {
+		replace(cmd, args[0], true)
+	},
+}
+
+// ReplaceResult is the outcome of tx speedup and tx cancel. Tx is the one of the
+// two transactions that was mined.
+type ReplaceResult struct {
+	Original    string   `json:"original" yaml:"original"`
+	Replacement string   `json:"replacement" yaml:"replacement"`
+	Nonce       uint64   `json:"nonce" yaml:"nonce"`
+	Tx          TxResult `json:"tx" yaml:"tx"`
+}
+
+// replace sends the replacement of the pending transaction hash, a copy of it or,
+// to cancel it, a transfer of nothing, and waits until one of the two is mined.
+// The transaction is sent to the network it was sent to, by its signer.
+func replace(cmd *cobra.Command, hash string, cancel bool) {
+	ctx := cmd.Context()
+	handleError(latestOnly())
+	if bump < 10 {
+		handleError(BKC.Errorf(BKC.KindUsage, "--bump must be at least 10, nodes refuse a replacement priced less than 10%% higher"))
+	}
+	entry, err := findEntry(hash)
+	handleError(err)
+	n, err := loadNetwork(entry.Network)
+	handleError(err)
+	c, err := dialNetwork(entry.Network, n)
+	handleError(err)
+	if entry.Outcome == "pending" {
+		entry, err = refreshEntry(ctx, c, entry)
+		handleError(err)
+	}
+	switch entry.Outcome {
+	case "pending":
+	case "replaced":
+		handleError(BKC.Errorf(BKC.KindUsage, "nonce %d of transaction %s is used by another transaction already", entry.Nonce, entry.Hash))
+	default:
+		handleError(BKC.Errorf(BKC.KindUsage, "transaction %s is mined already, in block %d", entry.Hash, entry.Block))
+	}
+
+	signer := common.HexToAddress(entry.Signer)
+	if viper.GetString("from") != "" {
+		from, err := fromAddress()
+		handleError(err)
+		if from != signer {
+			handleError(BKC.Errorf(BKC.KindUsage, "transaction %s was sent by %s, not by --from %s", entry.Hash, signer.Hex(), from.Hex()))
+		}
+	}
+	viper.Set("from", signer.Hex())
+
+	original, _, err := c.Backend().TransactionByHash(ctx, common.HexToHash(entry.Hash))
+	if err != nil && BKC.KindOf(err) == BKC.KindNotFound {
+		if !cancel {
+			handleError(BKC.Errorf(BKC.KindNotFound, "transaction %s isn't known to the node, it may have been dropped. tx cancel frees its nonce", entry.Hash))
+		}
+		original, err = nil, nil
+	}
+	handleError(err)
+
+	opts, err := transactOpts(ctx, c)
+	handleError(err)
+	id, err := chainID(ctx, c)
+	handleError(err)
+	build := replacement(ctx, c, original, entry.Nonce, cancel)
+	// the replacement isn't sent with send, which would wait for it alone
+	previewed, err := preview(ctx, c, opts, common.Address{}, build)
+	handleError(err)
+	if previewed {
+		return
+	}
+	tx, err := build(opts)
+	handleError(err)
+	fmt.Fprintf(os.Stderr, "Sent transaction %s replacing %s, waiting for one of them to be mined\n", tx.Hash().Hex(), entry.Hash)
+	sent := newJournalEntry(tx, signer, id)
+	sent.Network = entry.Network
+	sent.Replaces = entry.Hash
+	record(sent)
+
+	receipt, err := waitReplaced(ctx, c, signer, tx, entry.Hash)
+	handleError(err)
+	mined, other, minedTx := sent, entry, tx
+	if receipt.TxHash != tx.Hash() {
+		fmt.Fprintf(os.Stderr, "Warning: the original transaction %s was mined before its replacement\n", entry.Hash)
+		mined, other, minedTx = entry, sent, original
+	}
+	if minedTx != nil {
+		receipt, err = waitConfirmed(ctx, c, minedTx)
+		handleError(err)
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			err = c.FailedError(ctx, minedTx, signer, receipt)
+		}
+	}
+	other.Outcome = "replaced"
+	record(other)
+	record(mined.withReceipt(receipt, err))
+	handleError(err)
+
+	render(ReplaceResult{
+		Original:    entry.Hash,
+		Replacement: tx.Hash().Hex(),
+		Nonce:       entry.Nonce,
+		Tx:          newTxResult(receipt, signer),
+	})
+}
+
+// replacement builds the transaction replacing original at the nonce: a copy of
+// it with a higher gas price or, to cancel it, a transfer of nothing from the
+// signer to itself. original is nil when the node doesn't know of it, and the
+// gas price is then the one the node suggests.
+func replacement(ctx context.Context, c *BKC.Client, original *types.Transaction, nonce uint64, cancel bool) txBuilder {
+	return func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		to, value, data, gas := &opts.From, new(big.Int), []byte(nil), params.TxGas
+		if !cancel {
+			to, value, data, gas = original.To(), original.Value(), original.Data(), original.Gas()
+		}
+		head, err := c.Backend().HeaderByNumber(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var unsigned *types.Transaction
+		if head.BaseFee == nil {
+			price, err := c.Backend().SuggestGasPrice(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if original != nil {
+				price = higher(price, bumped(original.GasPrice()))
+			}
+			unsigned = types.NewTx(&types.LegacyTx{Nonce: nonce, GasPrice: price, Gas: gas, To: to, Value: value, Data: data})
+		} else {
+			tip, err := c.Backend().SuggestGasTipCap(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if original != nil {
+				tip = higher(tip, bumped(original.GasTipCap()))
+			}
+			feeCap := new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tip)
+			if original != nil {
+				feeCap = higher(feeCap, bumped(original.GasFeeCap()))
+			}
+			id, err := chainID(ctx, c)
+			if err != nil {
+				return nil, err
+			}
+			unsigned = types.NewTx(&types.DynamicFeeTx{ChainID: id, Nonce: nonce, GasTipCap: tip, GasFeeCap: feeCap, Gas: gas, To: to, Value: value, Data: data})
+		}
+
+		tx, err := opts.Signer(opts.From, unsigned)
+		if err != nil || opts.NoSend {
+			return tx, err
+		}
+		if err := c.Backend().SendTransaction(ctx, tx); err != nil {
+			return nil, err
+		}
+		return tx, nil
+	}
+}
+
+// bumped is the price raised by --bump percent, rounded up.
+func bumped(price *big.Int) *big.Int {
+	raised := new(big.Int).Mul(price, new(big.Int).SetUint64(100+bump))
+	raised.Add(raised, big.NewInt(99))
+	return raised.Div(raised, big.NewInt(100))
+}
+
+// higher is the higher of the two prices.
+func higher(a, b *big.Int) *big.Int {
+	if a.Cmp(b) < 0 {
+		return b
+	}
+	return a
+}
+
+// waitReplaced waits until the nonce of the replacement is used and returns the
+// receipt of the transaction that used it, the replacement or the original.
+func waitReplaced(ctx context.Context, c *BKC.Client, signer common.Address, replacement *types.Transaction, original string) (*types.Receipt, error) {
+	for {
+		// read before the receipts, so that a nonce used by neither is told apart
+		mined, err := c.Backend().NonceAt(ctx, signer, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, hash := range []common.Hash{replacement.Hash(), common.HexToHash(original)} {
+			receipt, err := c.Backend().TransactionReceipt(ctx, hash)
+			if err == nil {
+				return receipt, nil
+			}
+			if BKC.KindOf(err) != BKC.KindNotFound {
+				return nil, err
+			}
+		}
+		if mined > replacement.Nonce() {
+			return nil, BKC.Errorf(BKC.KindNotFound, "nonce %d of %s was used by another transaction than %s and its replacement", replacement.Nonce(), signer.Hex(), original)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func init() {
+	txCmd.AddCommand(txSpeedupCmd)
+	txCmd.AddCommand(txCancelCmd)
+	for _, cmd := range []*cobra.Command{txSpeedupCmd, txCancelCmd} {
+		cmd.Flags().Uint64Var(&bump, "bump", 10, "the percent the gas price, or tip and fee cap, is raised by, at least 10")
+	}
+	addWriteFlags(txSpeedupCmd, txCancelCmd)
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestBumped(t *testing.T) {
+	defer func(b uint64) { bump = b }(bump)
+	tests := []struct {
+		bump  uint64
+		price int64
+		want  int64
+	}{
+		{10, 100, 110},
+		// 111.1 is rounded up, 111 would be refused as less than 10% higher
+		{10, 101, 112},
+		{10, 1, 2},
+		{10, 0, 0},
+		{25, 1000000000, 1250000000},
+		{100, 7, 14},
+	}
+	for _, tt := range tests {
+		bump = tt.bump
+		if got := bumped(big.NewInt(tt.price)); got.Cmp(big.NewInt(tt.want)) != 0 {
+			t.Errorf("bumped(%d) by %d%% = %v, want %d", tt.price, tt.bump, got, tt.want)
+		}
+	}
+}
+
+func TestHigher(t *testing.T) {
+	tests := []struct{ a, b, want int64 }{
+		{1, 2, 2},
+		{2, 1, 2},
+		{3, 3, 3},
+	}
+	for _, tt := range tests {
+		if got := higher(big.NewInt(tt.a), big.NewInt(tt.b)); got.Int64() != tt.want {
+			t.Errorf("higher(%d, %d) = %v, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
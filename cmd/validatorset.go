@@ -16,15 +16,11 @@ limitations under the License.
 package cmd
 
 import (
-	"fmt"
-	"math/big"
+	"context"
 	"time"
+	BKC "win/Code/BKC"
 	IValidator "win/Code/IValidator"
-	Init "win/Code/Init"
-	"win/abi/validatorset"
-	ETHclient "win/client"
 
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/spf13/cobra"
 )
@@ -32,128 +28,207 @@ import (
 // validatorsetCmd represents the validatorset command
 var validatorsetCmd = &cobra.Command{
 	Use:   "validatorset",
-	Short: "query the validator set contract",
+	Short: "query and update the validator set contract",
 	Long: `The validator set contract contains the active validator set as well as the functions for updating 
 	the new validator set`,
+	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		client, err := ETHclient.Client()
+		flag, err := legacyFlag(cmd)
 		handleError(err)
-		name := "validator set"
-
-		//BKCValidatorSetAddress := "0x7eA14c6696EB86a9c7C7a8aCbaC4Bd7BFa80F974"
+		if flag == "" {
+			cmd.Help()
+			return
+		}
 
-		BKCValidatorSetInstance, err := validatorset.NewValidatorset(common.HexToAddress(BKCValidatorSetAddress), client)
+		c, err := newClient()
 		handleError(err)
 
-		in, err := cmd.Flags().GetBool("alreadyInit")
-		handleError(err)
-		cu, err := cmd.Flags().GetInt("currentValidatorSet")
+		switch flag {
+		case "alreadyInit":
+			render(GetInitStatus(cmd.Context(), c, BKC.ValidatorSetContract))
+		case "currentValidatorSet":
+			render(GetValidatorInSet(cmd.Context(), c, index))
+		case "currentValidatorSetMap":
+			addr, err := addressFlag(cmd, flag)
+			handleError(err)
+			render(GetValidatorSetMap(cmd.Context(), c, addr))
+		case "numberOfValidators":
+			render(GetNumberOfValdiator(cmd.Context(), c))
+		case "endTime":
+			render(GetEndTime(cmd.Context(), c))
+		case "validators":
+			render(GetActiveValidators(cmd.Context(), c))
+		}
+	},
+}
+
+var validatorsetInitStatusCmd = &cobra.Command{
+	Use:   "init-status",
+	Short: "whether the validator set contract is initialised",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
 		handleError(err)
-		cumap, err := cmd.Flags().GetString("currentValidatorSetMap")
+		render(GetInitStatus(cmd.Context(), c, BKC.ValidatorSetContract))
+	},
+}
+
+var validatorsetValidatorsCmd = &cobra.Command{
+	Use:   "validators",
+	Short: "query the active validator set",
+}
+
+var validatorsetValidatorsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the validators in the active set",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
 		handleError(err)
-		num, err := cmd.Flags().GetBool("numberOfValidators")
+		render(GetActiveValidators(cmd.Context(), c))
+	},
+}
+
+var validatorsetValidatorsCountCmd = &cobra.Command{
+	Use:   "count",
+	Short: "the number of validators in the active set",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
 		handleError(err)
-		end, err := cmd.Flags().GetBool("endTime")
+		render(GetNumberOfValdiator(cmd.Context(), c))
+	},
+}
+
+var validatorsetValidatorCmd = &cobra.Command{
+	Use:   "validator",
+	Short: "query a single validator of the active set",
+}
+
+var validatorsetValidatorGetCmd = &cobra.Command{
+	Use:   "get <index|address>",
+	Short: "get an active validator by its index (starting at 0) or its address",
+	Args:  indexOrAddressArg,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
 		handleError(err)
-		vl, err := cmd.Flags().GetBool("validators")
+		idx, addr, err := parseIndexOrAddress(args[0])
 		handleError(err)
-		if in {
-			Init.AlreadyInit(*client, BKCValidatorSetInstance, name)
-		} else if cu >= 0 {
-			GetValidatorInSet(BKCValidatorSetInstance, cu)
-		} else if cumap != "" {
-			GetValidatorSetMap(BKCValidatorSetInstance, cumap)
-		} else if num {
-			GetNumberOfValdiator(BKCValidatorSetInstance)
-		} else if end {
-			GetEndTime(BKCValidatorSetInstance)
-		} else if vl {
-			GetActiveValidators(BKCValidatorSetInstance)
+		if idx < 0 {
+			render(GetValidatorInSetByAddress(cmd.Context(), c, addr))
 		} else {
-			fmt.Println("please specify the field you want to query")
+			render(GetValidatorInSet(cmd.Context(), c, idx))
 		}
 	},
 }
 
+var validatorsetValidatorPositionCmd = &cobra.Command{
+	Use:   "position <address>",
+	Short: "the position (starting at 1) of a validator in the active set, 0 when it isn't active",
+	Args:  addressArgs("validator"),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
+		handleError(err)
+		render(GetValidatorSetMap(cmd.Context(), c, args[0]))
+	},
+}
+
+var validatorsetEndTimeCmd = &cobra.Command{
+	Use:   "end-time",
+	Short: "the end time of the current epoch",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
+		handleError(err)
+		render(GetEndTime(cmd.Context(), c))
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(validatorsetCmd)
+	validatorsetCmd.AddCommand(validatorsetInitStatusCmd)
+	validatorsetCmd.AddCommand(validatorsetValidatorsCmd)
+	validatorsetValidatorsCmd.AddCommand(validatorsetValidatorsListCmd)
+	validatorsetValidatorsCmd.AddCommand(validatorsetValidatorsCountCmd)
+	validatorsetCmd.AddCommand(validatorsetValidatorCmd)
+	validatorsetValidatorCmd.AddCommand(validatorsetValidatorGetCmd)
+	validatorsetValidatorCmd.AddCommand(validatorsetValidatorPositionCmd)
+	validatorsetCmd.AddCommand(validatorsetEndTimeCmd)
+
+	// the flags below are the original query interface, kept as deprecated aliases of the subcommands
 	validatorsetCmd.Flags().BoolVarP(&alreadyInit, "alreadyInit", "i", false, "the init status")
+	validatorsetCmd.Flags().MarkDeprecated("alreadyInit", "use `validatorset init-status` instead")
 
 	validatorsetCmd.Flags().IntVarP(&index, "currentValidatorSet", "s", -1, "the current validator set (specify index)")
+	validatorsetCmd.Flags().MarkDeprecated("currentValidatorSet", "use `validatorset validator get <index|address>` instead")
 
 	validatorsetCmd.Flags().StringVarP(&consensusAddress, "currentValidatorSetMap", "m", "", "the map of the current validator set (specify address)")
+	validatorsetCmd.Flags().MarkDeprecated("currentValidatorSetMap", "use `validatorset validator position <address>` instead")
 
 	validatorsetCmd.Flags().BoolVarP(&number_of_validators, "numberOfValidators", "n", false, "the number of validators")
+	validatorsetCmd.Flags().MarkDeprecated("numberOfValidators", "use `validatorset validators count` instead")
 
 	validatorsetCmd.Flags().BoolVarP(&endTime, "endTime", "e", false, "the end time of each epoch")
+	validatorsetCmd.Flags().MarkDeprecated("endTime", "use `validatorset end-time` instead")
 
 	validatorsetCmd.Flags().BoolVarP(&validators, "validators", "v", false, "the validators in active set")
-	// Here you will define your flags and configuration settings.
+	validatorsetCmd.Flags().MarkDeprecated("validators", "use `validatorset validators list` instead")
+}
 
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// validatorsetCmd.PersistentFlags().String("foo", "", "A help for foo")
+// SetSize is the number of validators in the active set.
+type SetSize struct {
+	Validators int64 `json:"validators" yaml:"validators"`
+}
 
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// validatorsetCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+// SetPosition is the value of currentValidatorSetMap for an address, which starts at 1.
+type SetPosition struct {
+	Validator string `json:"validator" yaml:"validator"`
+	InSet     bool   `json:"in_set" yaml:"in_set"`
+	Position  int64  `json:"position" yaml:"position"`
 }
 
-func PrintValue(instance *validatorset.Validatorset, name string, value interface{}) {
-	fmt.Println()
-	fmt.Println("The contract's", name, "field is", value)
-	fmt.Println()
+// EpochEnd is the time after which the validator set can be updated.
+type EpochEnd struct {
+	EndTime string `json:"end_time" yaml:"end_time"`
+	Unix    int64  `json:"unix" yaml:"unix"`
 }
 
-func GetValidatorInSet(instance *validatorset.Validatorset, i int) {
+func GetValidatorInSet(ctx context.Context, c *BKC.Client, i int) IValidator.View {
+	validator, err := c.ActiveValidator(ctx, i)
+	handleError(err)
+	return IValidator.NewView(i, validator)
+}
 
-	set, err := instance.CurrentValidatorSet(&bind.CallOpts{}, big.NewInt(int64(i)))
-	set = IValidator.Validator(set)
+func GetValidatorInSetByAddress(ctx context.Context, c *BKC.Client, addr common.Address) IValidator.View {
+	validator, idx, err := c.ActiveValidatorByAddress(ctx, addr)
 	handleError(err)
-	fmt.Println()
-	fmt.Println("Validator in validator set at index", i)
-	IValidator.PrintValidator(set)
-	fmt.Println()
+	return IValidator.NewView(idx, validator)
 }
 
-func GetValidatorSetMap(instance *validatorset.Validatorset, addr string) {
-	idx, err := instance.CurrentValidatorSetMap(&bind.CallOpts{}, common.HexToAddress(addr))
+func GetValidatorSetMap(ctx context.Context, c *BKC.Client, addr string) SetPosition {
+	idx, err := c.ActivePosition(ctx, common.HexToAddress(addr))
 	handleError(err)
-	if idx.Int64() == int64(0) {
-		fmt.Println()
-		fmt.Println("The validator address", addr, "is not in the active set")
-		fmt.Println()
-		return
-	}
-	fmt.Println()
-	fmt.Println("The index (starts at 1) of the validator", addr, "is", idx)
-	fmt.Println()
+	return SetPosition{Validator: common.HexToAddress(addr).Hex(), InSet: idx != 0, Position: int64(idx)}
 }
 
-func GetNumberOfValdiator(instance *validatorset.Validatorset) {
-	n, err := instance.NumberOfValidators(&bind.CallOpts{})
+func GetNumberOfValdiator(ctx context.Context, c *BKC.Client) SetSize {
+	n, err := c.NumberOfActiveValidators(ctx)
 	handleError(err)
-	fmt.Println()
-	fmt.Println("There are", n, "validators in the active set")
-	fmt.Println()
+	return SetSize{Validators: int64(n)}
 }
 
-func GetEndTime(instance *validatorset.Validatorset) {
-	endtime, err := instance.EndTime(&bind.CallOpts{})
+func GetEndTime(ctx context.Context, c *BKC.Client) EpochEnd {
+	endtime, err := c.EndTime(ctx)
 	handleError(err)
-	et := time.Unix(int64(endtime.Uint64()), 0)
-	fmt.Println()
-	fmt.Println("The end time for this epoch is", et)
-	fmt.Println()
+	return EpochEnd{EndTime: endtime.Format(time.RFC3339), Unix: endtime.Unix()}
 }
 
-func GetActiveValidators(instance *validatorset.Validatorset) {
-	vlds, err := instance.GetValidators(&bind.CallOpts{})
+func GetActiveValidators(ctx context.Context, c *BKC.Client) []IValidator.View {
+	vlds, err := c.ActiveValidators(ctx)
 	handleError(err)
+	views := []IValidator.View{}
 	for i, vld := range vlds {
-		fmt.Println()
-		fmt.Println("active validator", i+1)
-		IValidator.PrintValidator(IValidator.Validator(vld))
-		fmt.Println()
+		views = append(views, IValidator.NewView(i, vld))
 	}
+	return views
 }
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+	BKC "win/Code/BKC"
+	IValidator "win/Code/IValidator"
+	Output "win/Code/Output"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+)
+
+var validatorsetUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "rotate the active set at the end of the epoch",
+	Long: `Calls updateValidatorSet, which unbonds the active set, bonds the validators of
+the pool with the most power in its place and distributes the rewards of the
+epoch. Anyone can call it, with --from signing.
+
+The contract doesn't check the end time of the epoch, the command does: it is
+refused before then unless --force is given. The call loops over every
+validator of the pool for each seat, so its gas is estimated and shown first.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		force, err := cmd.Flags().GetBool("force")
+		handleError(err)
+		c, err := writeClient()
+		handleError(err)
+
+		end, err := c.CheckUpdate(ctx)
+		if force && errors.Is(err, BKC.ErrBeforeEndTime) {
+			fmt.Fprintf(os.Stderr, "Warning: the epoch ends at %s, updating before then because of --force\n", end.Format(time.RFC3339))
+		} else {
+			handleError(c.Explain(ctx, err, common.Address{}))
+			fmt.Fprintf(os.Stderr, "The epoch ended at %s\n", end.Format(time.RFC3339))
+		}
+		opts, err := transactOpts(ctx, c)
+		handleError(err)
+
+		before := GetActiveValidators(ctx, c)
+		var estimated uint64
+		if !dryRun {
+			// with --dry-run, previewOrSend shows the estimate
+			tx, err := estimate(ctx, c, opts, opts.From, c.ValidatorSet.UpdateValidatorSet)
+			handleError(err)
+			estimated = tx.Gas()
+			fmt.Fprintf(os.Stderr, "Estimated gas %d, at most %s ether in fees\n", estimated, Output.Ether(maxFee(tx)))
+		}
+
+		receipt, err := previewOrSend(ctx, c, opts, opts.From, c.ValidatorSet.UpdateValidatorSet)
+		handleError(err)
+		if receipt == nil {
+			return
+		}
+		next, err := c.EndTime(ctx)
+		handleError(err)
+		render(UpdateResult{
+			EndTime:      end.Format(time.RFC3339),
+			NextEndTime:  next.Format(time.RFC3339),
+			EstimatedGas: estimated,
+			Tx:           newTxResult(receipt, opts.From),
+			Before:       before,
+			After:        GetActiveValidators(ctx, c),
+		})
+	},
+}
+
+// UpdateResult is a rotation of the active set, with the set before and after it.
+type UpdateResult struct {
+	EndTime      string            `json:"end_time" yaml:"end_time"`
+	NextEndTime  string            `json:"next_end_time" yaml:"next_end_time"`
+	EstimatedGas uint64            `json:"estimated_gas" yaml:"estimated_gas"`
+	Tx           TxResult          `json:"tx" yaml:"tx"`
+	Before       []IValidator.View `json:"before" yaml:"before"`
+	After        []IValidator.View `json:"after" yaml:"after"`
+}
+
+func init() {
+	validatorsetCmd.AddCommand(validatorsetUpdateCmd)
+	addWriteFlags(validatorsetUpdateCmd)
+	validatorsetUpdateCmd.Flags().Bool("force", false, "update even though the epoch isn't over")
+}
@@ -16,15 +16,12 @@ limitations under the License.
 package cmd
 
 import (
-	"fmt"
-	"math/big"
+	"context"
 	"time"
+	BKC "win/Code/BKC"
 	IValidator "win/Code/IValidator"
-	Init "win/Code/Init"
-	vldpool "win/abi/vldpool"
-	ETHclient "win/client"
+	Output "win/Code/Output"
 
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/spf13/cobra"
 )
@@ -32,176 +29,307 @@ import (
 // vldpoolCmd represents the vldpool command
 var vldpoolCmd = &cobra.Command{
 	Use:   "vldpool",
-	Short: "query the validator pool contract",
+	Short: "query and operate validators in the validator pool contract",
 	Long:  `This contract consits of validators and their stake amount`,
+	Args:  cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		client, err := ETHclient.Client()
+		flag, err := legacyFlag(cmd)
 		handleError(err)
+		if flag == "" {
+			cmd.Help()
+			return
+		}
 
-		name := "validator pool"
+		c, err := newClient()
+		handleError(err)
 
-		//ValidatorPoolAddress := "0x282659B28f9acCaC9fBd81317Ce43b4044E5d4A7"
+		switch flag {
+		case "alreadyInit":
+			render(GetInitStatus(cmd.Context(), c, BKC.ValidatorPoolContract))
+		case "getValidator":
+			render(GetValidator(cmd.Context(), c, index))
+		case "numberOfValidators":
+			render(PoolSize{Validators: GetNumberOfValdiatorInPool(cmd.Context(), c)})
+		case "getAllValidators":
+			render(GetAllValidators(cmd.Context(), c))
+		case "validatorsMap":
+			addr, err := addressFlag(cmd, flag)
+			handleError(err)
+			render(GetValidatorsMap(cmd.Context(), c, addr))
+		case "validatorUnbondQueue":
+			addr, err := addressFlag(cmd, flag)
+			handleError(err)
+			render(GetVaidatorUnbondQueue(cmd.Context(), c, addr))
+		case "validatorJailQueue":
+			addr, err := addressFlag(cmd, flag)
+			handleError(err)
+			render(GetValidatorUnJailQueue(cmd.Context(), c, addr))
+		case "validatorRemoveQueue":
+			addr, err := addressFlag(cmd, flag)
+			handleError(err)
+			render(GetValidatorRemoveQueue(cmd.Context(), c, addr))
+		case "totalPower":
+			addr, err := addressFlag(cmd, flag)
+			handleError(err)
+			render(GetTotalPowerExcludeUnbonding(cmd.Context(), c, addr))
+		}
+	},
+}
 
-		ValidatorPoolInstance, err := vldpool.NewVldpool(common.HexToAddress(ValidatorPoolAddress), client)
+var vldpoolInitStatusCmd = &cobra.Command{
+	Use:   "init-status",
+	Short: "whether the validator pool contract is initialised",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
 		handleError(err)
+		render(GetInitStatus(cmd.Context(), c, BKC.ValidatorPoolContract))
+	},
+}
 
-		val, err := cmd.Flags().GetBool("alreadyInit")
+var vldpoolValidatorsCmd = &cobra.Command{
+	Use:   "validators",
+	Short: "query all the validators in the pool",
+}
+
+var vldpoolValidatorsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the validators in the pool",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
 		handleError(err)
-		num, err := cmd.Flags().GetBool("numberOfValidators")
+		render(GetAllValidators(cmd.Context(), c))
+	},
+}
+
+var vldpoolValidatorsCountCmd = &cobra.Command{
+	Use:   "count",
+	Short: "the number of validators in the pool",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
 		handleError(err)
-		v, err := cmd.Flags().GetBool("getAllValidators")
+		render(PoolSize{Validators: GetNumberOfValdiatorInPool(cmd.Context(), c)})
+	},
+}
+
+var vldpoolValidatorCmd = &cobra.Command{
+	Use:   "validator",
+	Short: "query a single validator in the pool",
+}
+
+var vldpoolValidatorGetCmd = &cobra.Command{
+	Use:   "get <index|address>",
+	Short: "get a validator by its index (starting at 0) or its address",
+	Args:  indexOrAddressArg,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
 		handleError(err)
-		m, err := cmd.Flags().GetString("validatorsMap")
+		idx, addr, err := parseIndexOrAddress(args[0])
 		handleError(err)
-		addr, err := cmd.Flags().GetString("validatorUnbondQueue")
+		if idx < 0 {
+			render(GetValidatorByAddress(cmd.Context(), c, addr))
+		} else {
+			render(GetValidator(cmd.Context(), c, idx))
+		}
+	},
+}
+
+var vldpoolValidatorPositionCmd = &cobra.Command{
+	Use:   "position <address>",
+	Short: "the position (starting at 1) of a validator in the pool, 0 when it isn't in the pool",
+	Args:  addressArgs("validator"),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
 		handleError(err)
-		addr2, err := cmd.Flags().GetString("validatorJailQueue")
+		render(GetValidatorsMap(cmd.Context(), c, args[0]))
+	},
+}
+
+var vldpoolValidatorPowerCmd = &cobra.Command{
+	Use:   "power <address>",
+	Short: "the total power (exclude unbonding delegation) of a validator",
+	Args:  addressArgs("validator"),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
 		handleError(err)
-		addr3, err := cmd.Flags().GetString("validatorRemoveQueue")
+		render(GetTotalPowerExcludeUnbonding(cmd.Context(), c, args[0]))
+	},
+}
+
+var vldpoolQueueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "query the time at which a validator leaves the unbond, jail or remove queue",
+}
+
+var vldpoolQueueUnbondCmd = &cobra.Command{
+	Use:   "unbond <address>",
+	Short: "the time at which the validator finishes unbonding",
+	Args:  addressArgs("validator"),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
 		handleError(err)
-		idx, err := cmd.Flags().GetInt("getValidator")
+		render(GetVaidatorUnbondQueue(cmd.Context(), c, args[0]))
+	},
+}
+
+var vldpoolQueueJailCmd = &cobra.Command{
+	Use:   "jail <address>",
+	Short: "the time at which the validator can unjail",
+	Args:  addressArgs("validator"),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
 		handleError(err)
-		addr4, err := cmd.Flags().GetString("totalPower")
+		render(GetValidatorUnJailQueue(cmd.Context(), c, args[0]))
+	},
+}
+
+var vldpoolQueueRemoveCmd = &cobra.Command{
+	Use:   "remove <address>",
+	Short: "the time at which the validator can remove itself from the pool",
+	Args:  addressArgs("validator"),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
 		handleError(err)
-		if val {
-			Init.AlreadyInit(*client, ValidatorPoolInstance, name)
-		} else if idx != -1 {
-			GetValidator(ValidatorPoolInstance, idx)
-		} else if num {
-			n := GetNumberOfValdiatorInPool(ValidatorPoolInstance)
-			fmt.Println()
-			fmt.Println("There are", n, "validators in the pool.")
-			fmt.Println()
-		} else if v {
-			GetAllValidators(ValidatorPoolInstance)
-		} else if m != "" {
-			GetValidatorsMap(ValidatorPoolInstance, m)
-		} else if addr != "" {
-			GetVaidatorUnbondQueue(ValidatorPoolInstance, addr)
-		} else if addr2 != "" {
-			GetValidatorUnJailQueue(ValidatorPoolInstance, addr2)
-		} else if addr3 != "" {
-			GetValidatorRemoveQueue(ValidatorPoolInstance, addr3)
-		} else if addr4 != "" {
-			GetTotalPowerExcludeUnbonding(ValidatorPoolInstance, addr4)
-		} else {
-			fmt.Println("please specify the field you want to query")
-		}
+		render(GetValidatorRemoveQueue(cmd.Context(), c, args[0]))
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(vldpoolCmd)
+	vldpoolCmd.AddCommand(vldpoolInitStatusCmd)
+	vldpoolCmd.AddCommand(vldpoolValidatorsCmd)
+	vldpoolValidatorsCmd.AddCommand(vldpoolValidatorsListCmd)
+	vldpoolValidatorsCmd.AddCommand(vldpoolValidatorsCountCmd)
+	vldpoolCmd.AddCommand(vldpoolValidatorCmd)
+	vldpoolValidatorCmd.AddCommand(vldpoolValidatorGetCmd)
+	vldpoolValidatorCmd.AddCommand(vldpoolValidatorPositionCmd)
+	vldpoolValidatorCmd.AddCommand(vldpoolValidatorPowerCmd)
+	vldpoolCmd.AddCommand(vldpoolQueueCmd)
+	vldpoolQueueCmd.AddCommand(vldpoolQueueUnbondCmd)
+	vldpoolQueueCmd.AddCommand(vldpoolQueueJailCmd)
+	vldpoolQueueCmd.AddCommand(vldpoolQueueRemoveCmd)
+
+	// the flags below are the original query interface, kept as deprecated aliases of the subcommands
 	vldpoolCmd.Flags().BoolVarP(&alreadyInit, "alreadyInit", "i", false, "the init status")
+	vldpoolCmd.Flags().MarkDeprecated("alreadyInit", "use `vldpool init-status` instead")
 
 	vldpoolCmd.Flags().BoolVarP(&number_of_validators, "numberOfValidators", "n", false, "the number of validators in the pool")
+	vldpoolCmd.Flags().MarkDeprecated("numberOfValidators", "use `vldpool validators count` instead")
 
 	vldpoolCmd.Flags().IntVarP(&index, "getValidator", "a", -1, "get the validator at the specified index (specify the index starts at 0 for first validator)")
+	vldpoolCmd.Flags().MarkDeprecated("getValidator", "use `vldpool validator get <index|address>` instead")
 
 	vldpoolCmd.Flags().BoolVarP(&getValidators, "getAllValidators", "v", false, "All the validators in the pool")
+	vldpoolCmd.Flags().MarkDeprecated("getAllValidators", "use `vldpool validators list` instead")
 
 	vldpoolCmd.Flags().StringVarP(&validatormap, "validatorsMap", "m", "", "The map of the validators in the pool (specify validator address)")
+	vldpoolCmd.Flags().MarkDeprecated("validatorsMap", "use `vldpool validator position <address>` instead")
 
 	vldpoolCmd.Flags().StringVarP(&consensusAddress, "validatorUnbondQueue", "u", "", "The unbond time of the validator (specify validator address")
+	vldpoolCmd.Flags().MarkDeprecated("validatorUnbondQueue", "use `vldpool queue unbond <address>` instead")
 
 	vldpoolCmd.Flags().StringVarP(&consensusAddress2, "validatorJailQueue", "j", "", "The unjail time of the validator (specify validator address)")
+	vldpoolCmd.Flags().MarkDeprecated("validatorJailQueue", "use `vldpool queue jail <address>` instead")
 
 	vldpoolCmd.Flags().StringVarP(&consensusAddress3, "validatorRemoveQueue", "r", "", "The remove time of the validator (specify validator address)")
+	vldpoolCmd.Flags().MarkDeprecated("validatorRemoveQueue", "use `vldpool queue remove <address>` instead")
 
 	vldpoolCmd.Flags().StringVarP(&consensusAddress4, "totalPower", "p", "", "the total power (exclude unbonding delegation) of a validator (specify validator address)")
+	vldpoolCmd.Flags().MarkDeprecated("totalPower", "use `vldpool validator power <address>` instead")
+}
 
-	// Here you will define your flags and configuration settings.
+// PoolSize is the number of validators in the pool.
+type PoolSize struct {
+	Validators int `json:"validators" yaml:"validators"`
+}
 
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// vldpoolCmd.PersistentFlags().String("foo", "", "A help for foo")
+// PoolPosition is the value of validatorsMap for an address, which starts at 1.
+type PoolPosition struct {
+	Validator string `json:"validator" yaml:"validator"`
+	InPool    bool   `json:"in_pool" yaml:"in_pool"`
+	Position  int64  `json:"position" yaml:"position"`
+}
 
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// vldpoolCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+// QueueTime is the time at which a validator leaves one of the queues of the pool.
+type QueueTime struct {
+	Validator string `json:"validator" yaml:"validator"`
+	Queue     string `json:"queue" yaml:"queue"`
+	Queued    bool   `json:"queued" yaml:"queued"`
+	Time      string `json:"time" yaml:"time"`
+	Unix      int64  `json:"unix" yaml:"unix"`
 }
 
-func GetNumberOfValdiatorInPool(instance *vldpool.Vldpool) int {
-	n, err := instance.NumberOfValidator(&bind.CallOpts{})
-	handleError(err)
-	return int(n.Int64())
+// Power is the stake of a validator plus its delegations.
+type Power struct {
+	Validator        string `json:"validator" yaml:"validator"`
+	ExcludeUnbonding bool   `json:"exclude_unbonding" yaml:"exclude_unbonding"`
+	PowerWei         string `json:"power_wei" yaml:"power_wei"`
+	PowerEther       string `json:"power_ether" yaml:"power_ether"`
 }
 
-func GetAllValidators(instance *vldpool.Vldpool) {
-	n := GetNumberOfValdiatorInPool(instance)
-	for i := 0; i < n; i++ {
-		validator, err := instance.Validators(&bind.CallOpts{}, big.NewInt(int64(i)))
-		handleError(err)
-		fmt.Println()
-		fmt.Println("Validator", i+1)
-		IValidator.PrintValidator(IValidator.Validator(validator))
-		fmt.Println()
-	}
+func GetNumberOfValdiatorInPool(ctx context.Context, c *BKC.Client) int {
+	n, err := c.NumberOfPoolValidators(ctx)
+	handleError(err)
+	return n
 }
 
-func GetValidatorsMap(instance *vldpool.Vldpool, m string) {
-	valmap, err := instance.ValidatorsMap(&bind.CallOpts{}, common.HexToAddress(m))
+func GetAllValidators(ctx context.Context, c *BKC.Client) []IValidator.View {
+	validators, err := c.PoolValidators(ctx)
 	handleError(err)
-	if valmap.Int64() == int64(0) {
-		fmt.Println()
-		fmt.Println("The validator address", m, "is not in the validator pool")
-		fmt.Println()
-		return
+	views := []IValidator.View{}
+	for i, validator := range validators {
+		views = append(views, IValidator.NewView(i, validator))
 	}
-	fmt.Println()
-	fmt.Println("The validator", m, "is at index", valmap, "(index starts at 1)")
-	fmt.Println()
+	return views
 }
 
-func GetVaidatorUnbondQueue(instance *vldpool.Vldpool, addr string) {
-	unbond, err := instance.ValidatorUnBondQueue(&bind.CallOpts{}, common.HexToAddress(addr))
+func GetValidatorsMap(ctx context.Context, c *BKC.Client, m string) PoolPosition {
+	position, err := c.PoolPosition(ctx, common.HexToAddress(m))
 	handleError(err)
-	fmt.Println()
-	if unbond.Int64() != int64(0) {
-		fmt.Println("The validator", addr, "will finish unbond at", time.Unix(int64(unbond.Uint64()), 0))
-	} else {
-		fmt.Println("The validator", addr, "isn't unbonding or the address is not a validator >> use the command vldpool -m <validator address> to check for index of the validator.")
-	}
-	fmt.Println()
+	return PoolPosition{Validator: common.HexToAddress(m).Hex(), InPool: position != 0, Position: int64(position)}
 }
 
-func GetValidatorUnJailQueue(instance *vldpool.Vldpool, addr2 string) {
-	unjail, err := instance.ValidatorJailQueue(&bind.CallOpts{}, common.HexToAddress(addr2))
+func GetVaidatorUnbondQueue(ctx context.Context, c *BKC.Client, addr string) QueueTime {
+	return getQueueTime(ctx, c, BKC.UnbondQueue, addr)
+}
+
+func GetValidatorUnJailQueue(ctx context.Context, c *BKC.Client, addr2 string) QueueTime {
+	return getQueueTime(ctx, c, BKC.JailQueue, addr2)
+}
+
+func GetValidatorRemoveQueue(ctx context.Context, c *BKC.Client, addr3 string) QueueTime {
+	return getQueueTime(ctx, c, BKC.RemoveQueue, addr3)
+}
+
+// getQueueTime reads a queue, where a zero time means the validator isn't
+// queued or isn't a validator at all.
+func getQueueTime(ctx context.Context, c *BKC.Client, queue BKC.Queue, addr string) QueueTime {
+	t, err := c.QueueTime(ctx, queue, common.HexToAddress(addr))
 	handleError(err)
-	fmt.Println()
-	if unjail.Int64() != int64(0) {
-		fmt.Println("The validator", addr2, "will be able to unjail at", time.Unix(int64(unjail.Uint64()), 0))
-	} else {
-		fmt.Println("The validator", addr2, "is not in jailed state, or the address provided is not a validator >> use the command vldpool -m <validator address> to check for index of the validator.")
+	q := QueueTime{Validator: common.HexToAddress(addr).Hex(), Queue: queue.String(), Queued: !t.IsZero()}
+	if q.Queued {
+		q.Unix = t.Unix()
+		q.Time = t.Format(time.RFC3339)
 	}
-	fmt.Println()
+	return q
 }
 
-func GetValidatorRemoveQueue(instance *vldpool.Vldpool, addr3 string) {
-	remove, err := instance.ValidatorRemoveQueue(&bind.CallOpts{}, common.HexToAddress(addr3))
+func GetValidator(ctx context.Context, c *BKC.Client, idx int) IValidator.View {
+	validator, err := c.PoolValidator(ctx, idx)
 	handleError(err)
-	fmt.Println()
-	if remove.Int64() != int64(0) {
-		fmt.Println("The validator", addr3, "can remove itself from the pool at", time.Unix(int64(remove.Uint64()), 0))
-	} else {
-		fmt.Println("The validator", addr3, "isn't in the remove queue or the address is not a validator >> use the command vldpool -m <validator address> to check for index of the validator.")
-	}
-	fmt.Println()
+	return IValidator.NewView(idx, validator)
 }
 
-func GetValidator(instance *vldpool.Vldpool, idx int) {
-	validator, err := instance.Validators(&bind.CallOpts{}, big.NewInt(int64(idx)))
+func GetValidatorByAddress(ctx context.Context, c *BKC.Client, addr common.Address) IValidator.View {
+	validator, idx, err := c.PoolValidatorByAddress(ctx, addr)
 	handleError(err)
-	fmt.Println()
-	fmt.Println("Validator at index", idx, "in the pool")
-	IValidator.PrintValidator(IValidator.Validator(validator))
-	fmt.Println()
+	return IValidator.NewView(idx, validator)
 }
 
-func GetTotalPowerExcludeUnbonding(instance *vldpool.Vldpool, addr4 string) {
-	power, err := instance.GetTotalPowerExcludeUnbonding(&bind.CallOpts{}, common.HexToAddress(addr4))
+func GetTotalPowerExcludeUnbonding(ctx context.Context, c *BKC.Client, addr4 string) Power {
+	power, err := c.TotalPowerExcludeUnbonding(ctx, common.HexToAddress(addr4))
 	handleError(err)
-	fmt.Println()
-	fmt.Println("The total power of the validator", addr4, "is", power)
-	fmt.Println()
+	return Power{Validator: common.HexToAddress(addr4).Hex(), ExcludeUnbonding: true, PowerWei: Output.Wei(power), PowerEther: Output.Ether(power)}
 }
@@ -0,0 +1,312 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+	BKC "win/Code/BKC"
+	Output "win/Code/Output"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/cobra"
+)
+
+// The write commands of the validator pool. They sign with --from, which is the
+// validator they act on.
+
+var vldpoolRegisterCmd = &cobra.Command{
+	Use:   "register --stake <ether>",
+	Short: "register the --from account as a validator of the pool",
+	Long: `Registers the --from account as a validator with the stake sent along, which
+must be at least 10 ether.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		stake, err := etherFlag(cmd, "stake")
+		handleError(err)
+		c, err := writeClient()
+		handleError(err)
+		opts, err := transactOpts(cmd.Context(), c)
+		handleError(err)
+
+		handleError(c.CheckRegister(cmd.Context(), opts.From, stake))
+		build := func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			opts.Value = stake
+			return c.ValidatorPool.RegisterValidator(opts)
+		}
+		transact(cmd.Context(), c, opts, opts.From, build)
+	},
+}
+
+var vldpoolTopUpCmd = &cobra.Command{
+	Use:   "topup --amount <ether>",
+	Short: "add to the stake of the --from validator",
+	Long:  `Adds the amount sent along to the stake of the --from validator, which must be unbonded.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		amount, err := etherFlag(cmd, "amount")
+		handleError(err)
+		c, err := writeClient()
+		handleError(err)
+		opts, err := transactOpts(cmd.Context(), c)
+		handleError(err)
+
+		handleError(c.CheckTopUp(cmd.Context(), opts.From, amount))
+		build := func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			opts.Value = amount
+			return c.ValidatorPool.ValidatorTopUp(opts)
+		}
+		transact(cmd.Context(), c, opts, opts.From, build)
+	},
+}
+
+var vldpoolWithdrawCmd = &cobra.Command{
+	Use:   "withdraw --amount <ether>",
+	Short: "take back part of the stake of the --from validator",
+	Long: `Returns part of the stake of the --from validator, which must be unbonded. The
+amount must be less than the stake. When the stake left is below 10 ether the
+validator is removed from the pool, and its stake and delegations are returned.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		amount, err := etherFlag(cmd, "amount")
+		handleError(err)
+		c, err := writeClient()
+		handleError(err)
+		opts, err := transactOpts(cmd.Context(), c)
+		handleError(err)
+
+		left, err := c.CheckWithdraw(cmd.Context(), opts.From, amount)
+		handleError(err)
+		if left.Cmp(BKC.MinValidatorStake) < 0 {
+			fmt.Fprintf(os.Stderr, "Warning: the stake left, %s ether, is below the minimum of %s ether. reEvaluateValidator will remove %s from the pool and return its stake and all its delegations.\n",
+				Output.Ether(left), Output.Ether(BKC.MinValidatorStake), opts.From.Hex())
+		}
+		build := func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return c.ValidatorPool.WithdrawFund(opts, amount)
+		}
+		transact(cmd.Context(), c, opts, opts.From, build)
+	},
+}
+
+var vldpoolExitCmd = &cobra.Command{
+	Use:   "exit",
+	Short: "take the --from validator out of the pool",
+	Long: `Leaving the pool takes two transactions. validatorRemove puts the validator in
+the remove queue, then once the remove period is over removeRemovingValidatorFromQueue
+takes it out of the pool and returns its stake and all its delegations.
+
+exit sends the first one and shows when the exit can be finished. With --wait it
+waits until then and sends the second one. --resume finishes an exit started
+earlier, waiting first when --wait is given as well. --dry-run and --unsigned-out
+cover the next transaction only, so they can't be given with --wait.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		wait, err := cmd.Flags().GetBool("wait")
+		handleError(err)
+		resume, err := cmd.Flags().GetBool("resume")
+		handleError(err)
+		if wait && (dryRun || unsignedOut != "") {
+			handleError(BKC.Errorf(BKC.KindUsage, "--wait can't be used with --dry-run or --unsigned-out, which only cover the next transaction of the exit"))
+		}
+		c, err := writeClient()
+		handleError(err)
+		opts, err := transactOpts(ctx, c)
+		handleError(err)
+		validator := opts.From
+
+		ready, err := c.QueueTime(ctx, BKC.RemoveQueue, validator)
+		handleError(err)
+		result := ExitResult{Validator: validator.Hex()}
+		if resume {
+			if ready.IsZero() {
+				handleError(BKC.Errorf(BKC.KindNotFound, "no exit of %s in progress, run `vldpool exit` without --resume to start one", validator.Hex()))
+			}
+		} else {
+			if !ready.IsZero() {
+				handleError(BKC.Errorf(BKC.KindUsage, "the exit of %s is already in progress, it can be finished after %s with `vldpool exit --resume`", validator.Hex(), ready.Format(time.RFC3339)))
+			}
+			handleError(c.CheckRemove(ctx, validator))
+			receipt, err := previewOrSend(ctx, c, opts, validator, c.ValidatorPool.ValidatorRemove)
+			handleError(err)
+			if receipt == nil {
+				return
+			}
+			result.RemoveTx = receipt.TxHash.Hex()
+			ready, err = c.QueueTime(ctx, BKC.RemoveQueue, validator)
+			handleError(err)
+		}
+		result.ReadyTime = ready.Format(time.RFC3339)
+		result.ReadyUnix = ready.Unix()
+
+		now, err := c.Now(ctx)
+		handleError(err)
+		if !now.After(ready) && !wait {
+			result.Stage = "queued"
+			render(result)
+			fmt.Fprintf(os.Stderr, "Run `vldpool exit --resume` after %s to finish the exit\n", ready.Format(time.RFC3339))
+			return
+		}
+		handleError(waitForBlockTime(ctx, c, ready))
+
+		_, err = c.CheckLeaveQueue(ctx, BKC.RemoveQueue, validator)
+		handleError(err)
+		receipt, err := previewOrSend(ctx, c, opts, validator, c.ValidatorPool.RemoveRemovingValidatorFromQueue)
+		handleError(err)
+		if receipt == nil {
+			return
+		}
+		result.FinishTx = receipt.TxHash.Hex()
+		result.Stage = "removed"
+		render(result)
+	},
+}
+
+var vldpoolUnbondCompleteCmd = &cobra.Command{
+	Use:   "unbond-complete",
+	Short: "finish the unbonding of the --from validator",
+	Long: `Calls removeUnBondingValidatorFromQueue, which makes the --from validator unbonded
+once its time in the unbond queue is over. It is refused before then, unless --wait
+is given, in which case the command waits and retries.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		leaveQueue(cmd, BKC.UnbondQueue, GetVaidatorUnbondQueue, func(c *BKC.Client) txBuilder {
+			return c.ValidatorPool.RemoveUnBondingValidatorFromQueue
+		})
+	},
+}
+
+var vldpoolUnjailCmd = &cobra.Command{
+	Use:   "unjail",
+	Short: "take the --from validator out of jail",
+	Long: `Calls removeJailValidatorFromQueue, which unjails the --from validator and makes
+it unbonded once its time in the jail queue is over. It is refused before then,
+unless --wait is given, in which case the command waits and retries.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		leaveQueue(cmd, BKC.JailQueue, GetValidatorUnJailQueue, func(c *BKC.Client) txBuilder {
+			return c.ValidatorPool.RemoveJailValidatorFromQueue
+		})
+	},
+}
+
+var vldpoolJailCmd = &cobra.Command{
+	Use:   "jail <validator>",
+	Short: "jail a validator of the active set, signing with the admin account",
+	Long: `Calls jailValidator, which takes the validator out of the active set, puts it in
+the jail queue and slashes 5 ether from its stake. When the stake left is below
+10 ether the validator is removed from the pool and all its delegations are
+returned. Only the admin account of the validator pool can jail, so --from must
+be that account.`,
+	Args: addressArgs("validator"),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		c, err := writeClient()
+		handleError(err)
+		from, err := fromAddress()
+		handleError(err)
+		validator := common.HexToAddress(args[0])
+
+		stake, left, err := c.CheckJail(ctx, from, validator)
+		handleError(err)
+		opts, err := transactOpts(ctx, c)
+		handleError(err)
+		fmt.Fprintf(os.Stderr, "Jailing %s: its stake of %s ether will be %s ether after the slash of %s ether\n",
+			validator.Hex(), Output.Ether(stake), Output.Ether(left), Output.Ether(BKC.JailSlash))
+		if left.Cmp(BKC.MinValidatorStake) < 0 {
+			delegators, err := c.Delegators(ctx, validator)
+			handleError(err)
+			fmt.Fprintf(os.Stderr, "Warning: the stake left is below the minimum of %s ether. reEvaluateValidator will remove %s from the pool and force-remove its %d delegators.\n",
+				Output.Ether(BKC.MinValidatorStake), validator.Hex(), len(delegators))
+		}
+		build := func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return c.ValidatorPool.JailValidator(opts, validator)
+		}
+		transact(ctx, c, opts, validator, build)
+	},
+}
+
+// leaveQueue takes the --from validator out of the queue with the transaction
+// made by build. With --wait it waits for the queue time and retries while the
+// contract says the queue time isn't over, which happens when blocks lag behind.
+func leaveQueue(cmd *cobra.Command, queue BKC.Queue, read func(context.Context, *BKC.Client, string) QueueTime, build func(*BKC.Client) txBuilder) {
+	ctx := cmd.Context()
+	wait, err := cmd.Flags().GetBool("wait")
+	handleError(err)
+	retries, err := cmd.Flags().GetInt("retries")
+	handleError(err)
+	c, err := writeClient()
+	handleError(err)
+	opts, err := transactOpts(ctx, c)
+	handleError(err)
+	validator := opts.From
+
+	// only the sends count against --retries, not the waits for the queue time
+	retried := 0
+	for {
+		q := read(ctx, c, validator.Hex())
+		_, err := c.CheckLeaveQueue(ctx, queue, validator)
+		if wait && inProgress(err) {
+			handleError(waitForBlockTime(ctx, c, time.Unix(q.Unix, 0)))
+			continue
+		}
+		handleError(err)
+
+		receipt, err := previewOrSend(ctx, c, opts, validator, build(c))
+		if wait && inProgress(err) && retried < retries {
+			retried++
+			fmt.Fprintf(os.Stderr, "%v, retrying in %s\n", err, waitPoll)
+			select {
+			case <-ctx.Done():
+				handleError(ctx.Err())
+			case <-time.After(waitPoll):
+			}
+			continue
+		}
+		handleError(err)
+		if receipt != nil {
+			render(newTxResult(receipt, validator))
+		}
+		return
+	}
+}
+
+// inProgress tells whether err says that a queue time isn't over yet.
+func inProgress(err error) bool {
+	return errors.Is(err, BKC.ErrUnbondInProgress) || errors.Is(err, BKC.ErrJailInProgress) || errors.Is(err, BKC.ErrRemoveInProgress)
+}
+
+// ExitResult is the progress of the exit of a validator. Stage is queued until
+// the validator is removed from the pool.
+type ExitResult struct {
+	Validator string `json:"validator" yaml:"validator"`
+	Stage     string `json:"stage" yaml:"stage"`
+	ReadyTime string `json:"ready_time" yaml:"ready_time"`
+	ReadyUnix int64  `json:"ready_unix" yaml:"ready_unix"`
+	RemoveTx  string `json:"remove_tx,omitempty" yaml:"remove_tx,omitempty"`
+	FinishTx  string `json:"finish_tx,omitempty" yaml:"finish_tx,omitempty"`
+}
+
+func init() {
+	vldpoolCmd.AddCommand(vldpoolRegisterCmd)
+	vldpoolCmd.AddCommand(vldpoolTopUpCmd)
+	vldpoolCmd.AddCommand(vldpoolWithdrawCmd)
+	vldpoolCmd.AddCommand(vldpoolExitCmd)
+	vldpoolCmd.AddCommand(vldpoolUnbondCompleteCmd)
+	vldpoolCmd.AddCommand(vldpoolUnjailCmd)
+	vldpoolCmd.AddCommand(vldpoolJailCmd)
+	addWriteFlags(vldpoolRegisterCmd, vldpoolTopUpCmd, vldpoolWithdrawCmd, vldpoolExitCmd, vldpoolUnbondCompleteCmd, vldpoolUnjailCmd, vldpoolJailCmd)
+
+	vldpoolRegisterCmd.Flags().String("stake", "", "the stake in ether, at least 10")
+	vldpoolTopUpCmd.Flags().String("amount", "", "the amount to add in ether")
+	vldpoolWithdrawCmd.Flags().String("amount", "", "the amount to withdraw in ether")
+	vldpoolExitCmd.Flags().Bool("wait", false, "wait for the end of the remove period and finish the exit")
+	vldpoolExitCmd.Flags().Bool("resume", false, "finish an exit started earlier")
+	for _, cmd := range []*cobra.Command{vldpoolUnbondCompleteCmd, vldpoolUnjailCmd} {
+		cmd.Flags().Bool("wait", false, "wait for the end of the queue time instead of refusing")
+		cmd.Flags().Int("retries", 3, "with --wait, how many times to retry while the contract says the queue time isn't over")
+	}
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+	BKC "win/Code/BKC"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// waitPoll is how often the latest block is read while waiting.
+const waitPoll = 5 * time.Second
+
+// waitForBlockTime blocks until the latest block is past t, printing a countdown
+// to stderr. The simulated chain doesn't mine on its own, so its clock is moved
+// forward instead.
+func waitForBlockTime(ctx context.Context, c *BKC.Client, t time.Time) error {
+	for {
+		now, err := c.Now(ctx)
+		if err != nil {
+			return err
+		}
+		if now.After(t) {
+			return nil
+		}
+		if simChain != nil {
+			if err := simChain.AdjustTime(t.Sub(now) + time.Second); err != nil {
+				return err
+			}
+			simChain.Commit()
+			continue
+		}
+
+		left := t.Sub(now) + time.Second
+		fmt.Fprintf(os.Stderr, "Waiting until %s, %s left\n", t.Format(time.RFC3339), left.Round(time.Second))
+		if left > waitPoll {
+			left = waitPoll
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(left):
+		}
+	}
+}
+
+// waitConfirmed waits until the transaction is mined and --confirmations blocks,
+// its own included, are on the chain. The receipt is read again at the end, and
+// when its block was reorganised away the transaction is waited for again.
+func waitConfirmed(ctx context.Context, c *BKC.Client, tx *types.Transaction) (*types.Receipt, error) {
+	for {
+		receipt, err := bind.WaitMined(ctx, c.Backend(), tx)
+		if err != nil {
+			return nil, err
+		}
+		if confirmations <= 1 {
+			return receipt, nil
+		}
+		mined := receipt.BlockNumber.Uint64()
+		for seen := mined; ; {
+			head, err := c.Backend().HeaderByNumber(ctx, nil)
+			if err != nil {
+				return nil, err
+			}
+			number := head.Number.Uint64()
+			if number+1 >= mined+confirmations {
+				break
+			}
+			if simChain != nil {
+				simChain.Commit()
+				continue
+			}
+			if number != seen {
+				fmt.Fprintf(os.Stderr, "%d of %d confirmations\n", number-mined+1, confirmations)
+				seen = number
+			}
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Second):
+			}
+		}
+
+		again, err := c.Backend().TransactionReceipt(ctx, tx.Hash())
+		if err == nil && again.BlockHash == receipt.BlockHash {
+			return again, nil
+		}
+		if err != nil && !errors.Is(err, ethereum.NotFound) {
+			return nil, err
+		}
+		fmt.Fprintf(os.Stderr, "The block of transaction %s was reorganised away, waiting for it again\n", tx.Hash().Hex())
+	}
+}
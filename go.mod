@@ -4,8 +4,11 @@ go 1.16
 
 require (
 	github.com/ethereum/go-ethereum v1.10.4
+	github.com/prometheus/tsdb v0.7.1
 	github.com/spf13/cobra v1.2.1
+	github.com/spf13/pflag v1.0.5
 	github.com/spf13/viper v1.8.1
 	golang.org/x/sys v0.0.0-20210630005230-0f9fa26af87c // indirect
 	golang.org/x/text v0.3.6 // indirect
+	gopkg.in/yaml.v2 v2.4.0
 )